@@ -0,0 +1,269 @@
+package ecdaa
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * Attribute-based credential (BBS+ style).
+ *
+ * The issuer signs the member's secret key together with a vector of
+ * attributes, and the member can later prove possession of the credential
+ * while disclosing only a chosen subset of the attributes.
+ */
+
+/**
+ * AttributeISK: Issuer's Secret Key for attribute credentials.
+ */
+type AttributeISK struct {
+	X *FP256BN.BIG
+}
+
+/**
+ * AttributeIPK: Issuer's Public Key for attribute credentials.
+ *
+ * H0, HSK and H are derived from fixed labels with hash-to-curve,
+ * so that they can be recomputed by everyone (see VerifyAttributeIPK).
+ */
+type AttributeIPK struct {
+	W   *FP256BN.ECP2
+	H0  *FP256BN.ECP
+	HSK *FP256BN.ECP
+	H   []*FP256BN.ECP
+	C   *FP256BN.BIG
+	S   *FP256BN.BIG
+}
+
+type AttributeIssuer struct {
+	Ipk AttributeIPK
+	Isk AttributeISK
+}
+
+/**
+ * Credential on the member's secret key and attributes.
+ * A^(E + x) = g1 * H0^S * HSK^sk * Π H_i^Attributes_i
+ */
+type AttributeCredential struct {
+	A          *FP256BN.ECP
+	E          *FP256BN.BIG
+	S          *FP256BN.BIG
+	Attributes []*FP256BN.BIG
+}
+
+/**
+ * Attribute from integer, e.g. firmware version.
+ */
+func AttributeFromUint64(v uint64) *FP256BN.BIG {
+	var buf [FP256BN.MODBYTES]byte
+	binary.BigEndian.PutUint64(buf[len(buf)-8:], v)
+
+	return FP256BN.FromBytes(buf[:])
+}
+
+/**
+ * Attribute from arbitrary bytes, e.g. device model or region.
+ */
+func AttributeFromBytes(v []byte) *FP256BN.BIG {
+	hash := amcl_utils.NewHash()
+	hash.WriteBytes([]byte("ecdaa-attribute"), v)
+
+	return hash.SumToBIG()
+}
+
+func attributeGenerators(count int) (*FP256BN.ECP, *FP256BN.ECP, []*FP256BN.ECP, error) {
+	H0, err := generatorFromLabel([]byte("ecdaa-attribute-h0"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	HSK, err := generatorFromLabel([]byte("ecdaa-attribute-hsk"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	H := make([]*FP256BN.ECP, count)
+
+	for i := 0; i < count; i++ {
+		H[i], err = generatorFromLabel([]byte(fmt.Sprintf("ecdaa-attribute-h%d", i+1)))
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return H0, HSK, H, nil
+}
+
+/**
+ * Generate AttributeISK with random.
+ */
+func RandomAttributeISK(rng *core.RAND) AttributeISK {
+	x := FP256BN.Random(rng)
+	x.Mod(amcl_utils.P())
+
+	return AttributeISK{X: x}
+}
+
+/**
+ * Generate AttributeIPK supporting `count` attributes.
+ */
+func RandomAttributeIPK(isk *AttributeISK, count int, rng *core.RAND) (AttributeIPK, error) {
+	var ipk AttributeIPK
+
+	H0, HSK, H, err := attributeGenerators(count)
+	if err != nil {
+		return ipk, err
+	}
+
+	// W = g2^x
+	W := amcl_utils.G2().Mul(isk.X)
+
+	// proof of knowledge of x
+	//     U = g2^r
+	//     c = H(U | g2 | W)
+	//     s = r + cx
+	r := amcl_utils.RandomBig(rng)
+	U := amcl_utils.G2().Mul(r)
+
+	hash := amcl_utils.NewHash()
+	hash.WriteECP2(U, amcl_utils.G2(), W)
+	c := hash.SumToBIG()
+
+	ipk.W = W
+	ipk.H0 = H0
+	ipk.HSK = HSK
+	ipk.H = H
+	ipk.C = c
+	ipk.S = schnorrResponse(r, c, isk.X)
+
+	return ipk, nil
+}
+
+/**
+ * Check AttributeIPK is valid.
+ */
+func VerifyAttributeIPK(ipk *AttributeIPK) error {
+	H0, HSK, H, err := attributeGenerators(len(ipk.H))
+	if err != nil {
+		return err
+	}
+
+	if !H0.Equals(ipk.H0) || !HSK.Equals(ipk.HSK) {
+		return fmt.Errorf("attribute IPK generators are not valid")
+	}
+
+	for i := range H {
+		if !H[i].Equals(ipk.H[i]) {
+			return fmt.Errorf("attribute IPK generator %v is not valid", i)
+		}
+	}
+
+	// U = g2^s * W^{-c}
+	U := amcl_utils.G2().Mul(ipk.S)
+	U.Sub(ipk.W.Mul(ipk.C))
+
+	hash := amcl_utils.NewHash()
+	hash.WriteECP2(U, amcl_utils.G2(), ipk.W)
+	c := hash.SumToBIG()
+
+	if FP256BN.Comp(c, ipk.C) != 0 {
+		return fmt.Errorf("attribute IPK is not valid")
+	}
+
+	return nil
+}
+
+func RandomAttributeIssuer(count int, rng *core.RAND) (AttributeIssuer, error) {
+	isk := RandomAttributeISK(rng)
+	ipk, err := RandomAttributeIPK(&isk, count, rng)
+
+	return AttributeIssuer{Ipk: ipk, Isk: isk}, err
+}
+
+/**
+ * B = g1 * H0^s * Q * Π H_i^m_i
+ */
+func attributeBase(ipk *AttributeIPK, Q *FP256BN.ECP, s *FP256BN.BIG, attributes []*FP256BN.BIG) *FP256BN.ECP {
+	B := amcl_utils.G1()
+	B.Add(ipk.H0.Mul(s))
+	B.Add(Q)
+	B.Add(multiExp(ipk.H[:len(attributes)], attributes))
+
+	return B
+}
+
+/**
+ * Step2. generate request for join of attribute credential (by Member)
+ *
+ * Q = HSK^sk, proven with a Schnorr proof over the nonce given by the issuer.
+ */
+func GenAttributeJoinReq(nonce []byte, ipk *AttributeIPK, rng *core.RAND) (*JoinRequest, *FP256BN.BIG, error) {
+	sk := amcl_utils.RandomBig(rng)
+	Q := ipk.HSK.Mul(sk)
+
+	proof := proveSchnorr(nonce, nil, sk, ipk.HSK, Q, rng)
+
+	req := JoinRequest{
+		Proof: proof,
+		Q:     Q,
+	}
+
+	return &req, sk, nil
+}
+
+func VerifyAttributeJoinReq(req *JoinRequest, nonce []byte, ipk *AttributeIPK) error {
+	return verifySchnorr(nonce, nil, req.Proof, ipk.HSK, req.Q)
+}
+
+/**
+ * Step3. make attribute credential for join (by Issuer)
+ */
+func (issuer *AttributeIssuer) MakeCred(req *JoinRequest, attributes []*FP256BN.BIG, rng *core.RAND) (*AttributeCredential, error) {
+	if len(attributes) > len(issuer.Ipk.H) {
+		return nil, fmt.Errorf("too many attributes: %v > %v", len(attributes), len(issuer.Ipk.H))
+	}
+
+	e := amcl_utils.RandomBig(rng)
+	s := amcl_utils.RandomBig(rng)
+
+	B := attributeBase(&issuer.Ipk, req.Q, s, attributes)
+
+	// A = B^(1 / (e + x))
+	inv := FP256BN.Modadd(e, issuer.Isk.X, amcl_utils.P())
+	inv.Invmodp(amcl_utils.P())
+
+	cred := AttributeCredential{
+		A:          B.Mul(inv),
+		E:          e,
+		S:          s,
+		Attributes: attributes,
+	}
+
+	return &cred, nil
+}
+
+/**
+ * Check the attribute credential with the member's Q = HSK^sk.
+ * e(A, W * g2^e) = e(B, g2)
+ */
+func VerifyAttributeCred(cred *AttributeCredential, Q *FP256BN.ECP, ipk *AttributeIPK) error {
+	if len(cred.Attributes) > len(ipk.H) {
+		return fmt.Errorf("too many attributes: %v > %v", len(cred.Attributes), len(ipk.H))
+	}
+
+	B := attributeBase(ipk, Q, cred.S, cred.Attributes)
+
+	W := amcl_utils.G2().Mul(cred.E)
+	W.Add(ipk.W)
+
+	if !pairingEquals(W, cred.A, amcl_utils.G2(), B) {
+		return fmt.Errorf("Ate(W * g2^e, cred.A) != Ate(g2(), B)")
+	}
+
+	return nil
+}
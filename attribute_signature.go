@@ -0,0 +1,398 @@
+package ecdaa
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * Signature with attribute credential.
+ *
+ * APrime = A^r1, ABar = APrime^x, D = B^r1 * H0^-r2 are randomized from
+ * the credential, and Z* are the responses of the proof which shows
+ * the knowledge of (e, r2, r3, s', sk, hidden attributes).
+ * K = Base^sk is the pseudonym, Base is derived from the basename
+ * (or random for unlinkable signature).
+ */
+type AttributeSignature struct {
+	APrime *FP256BN.ECP
+	ABar   *FP256BN.ECP
+	D      *FP256BN.ECP
+	Base   *FP256BN.ECP
+	K      *FP256BN.ECP
+
+	SmallC *FP256BN.BIG
+	SmallN *FP256BN.BIG
+
+	ZE  *FP256BN.BIG
+	ZR2 *FP256BN.BIG
+	ZR3 *FP256BN.BIG
+	ZS  *FP256BN.BIG
+	ZSK *FP256BN.BIG
+
+	Disclosed   map[int]*FP256BN.BIG
+	ZAttributes map[int]*FP256BN.BIG
+
+	Predicates []*PredicateProof
+}
+
+type AttributeSigner struct {
+	cred *AttributeCredential
+	sk   *FP256BN.BIG
+	ipk  *AttributeIPK
+}
+
+func NewAttributeSigner(cred *AttributeCredential, sk *FP256BN.BIG, ipk *AttributeIPK) AttributeSigner {
+	var signer = AttributeSigner{
+		cred: cred,
+		sk:   sk,
+		ipk:  ipk,
+	}
+
+	return signer
+}
+
+func sortedKeys(m map[int]*FP256BN.BIG) []int {
+	keys := make([]int, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Ints(keys)
+
+	return keys
+}
+
+// c' = H(APrime, ABar, D, Base, K, T1, T2, T3, disclosed, predicates, basename, message)
+func attributeChallenge(signature *AttributeSignature, T1, T2, T3 *FP256BN.ECP, TC []*FP256BN.ECP, G *FP256BN.ECP, basename, message []byte) *FP256BN.BIG {
+	hash := amcl_utils.NewHash()
+	hash.WriteECP(signature.APrime, signature.ABar, signature.D, signature.Base, signature.K, T1, T2, T3)
+
+	for _, i := range sortedKeys(signature.Disclosed) {
+		hash.WriteBIG(FP256BN.NewBIGint(i), signature.Disclosed[i])
+	}
+
+	for i, proof := range signature.Predicates {
+		proof.Predicate.writeHash(&hash)
+		hash.WriteECP(proof.commitment(G), TC[i])
+	}
+
+	hash.WriteBytes(basename, message)
+
+	return hash.SumToBIG()
+}
+
+// c = H(n | c')
+func finalChallenge(n, cDash *FP256BN.BIG) *FP256BN.BIG {
+	hash := amcl_utils.NewHash()
+	hash.WriteBIG(n, cDash)
+
+	return hash.SumToBIG()
+}
+
+func nonZeroRandom(rng *core.RAND) *FP256BN.BIG {
+	for {
+		r := amcl_utils.RandomBig(rng)
+		r.Mod(amcl_utils.P())
+
+		if FP256BN.Comp(r, FP256BN.NewBIGint(0)) != 0 {
+			return r
+		}
+	}
+}
+
+/**
+ * Sign with the attribute credential, disclosing the attributes of the given indexes.
+ */
+func (signer AttributeSigner) Sign(message, basename []byte, disclosed []int, rng *core.RAND) (*AttributeSignature, error) {
+	return signer.SignWithPredicates(message, basename, disclosed, nil, rng)
+}
+
+/**
+ * Sign with the attribute credential, disclosing the attributes of the given indexes
+ * and proving the predicates over the hidden attributes.
+ */
+func (signer AttributeSigner) SignWithPredicates(message, basename []byte, disclosed []int, predicates []Predicate, rng *core.RAND) (*AttributeSignature, error) {
+	var signature AttributeSignature
+
+	cred := signer.cred
+	ipk := signer.ipk
+	p := amcl_utils.P()
+
+	signature.Disclosed = map[int]*FP256BN.BIG{}
+
+	for _, i := range disclosed {
+		if i < 0 || i >= len(cred.Attributes) {
+			return nil, fmt.Errorf("attribute index out of range: %v", i)
+		}
+
+		signature.Disclosed[i] = cred.Attributes[i]
+	}
+
+	if basename == nil {
+		signature.Base = amcl_utils.RandomECP(rng)
+	} else {
+		B, err := basenamePoint(basename)
+		if err != nil {
+			return nil, err
+		}
+
+		signature.Base = B
+	}
+
+	Q := ipk.HSK.Mul(signer.sk)
+	B := attributeBase(ipk, Q, cred.S, cred.Attributes)
+
+	r1 := nonZeroRandom(rng)
+	r2 := amcl_utils.RandomBig(rng)
+	r3 := FP256BN.NewBIGcopy(r1)
+	r3.Invmodp(p)
+
+	// APrime = A^r1, ABar = APrime^-e * B^r1, D = B^r1 * H0^-r2
+	signature.APrime = cred.A.Mul(r1)
+
+	signature.ABar = B.Mul(r1)
+	signature.ABar.Add(negECP(signature.APrime).Mul(cred.E))
+
+	signature.D = B.Mul(r1)
+	signature.D.Sub(ipk.H0.Mul(r2))
+
+	signature.K = signature.Base.Mul(signer.sk)
+
+	// s' = s - r2 * r3
+	sPrime := modSub(cred.S, FP256BN.Modmul(r2, r3, p))
+
+	rE := amcl_utils.RandomBig(rng)
+	rR2 := amcl_utils.RandomBig(rng)
+	rR3 := amcl_utils.RandomBig(rng)
+	rS := amcl_utils.RandomBig(rng)
+	rSK := amcl_utils.RandomBig(rng)
+	rAttributes := map[int]*FP256BN.BIG{}
+
+	// T1 = APrime^-rE * H0^rR2
+	T1 := negECP(signature.APrime).Mul(rE)
+	T1.Add(ipk.H0.Mul(rR2))
+
+	// T2 = D^rR3 * H0^-rS * HSK^-rSK * Π H_j^-rM_j
+	T2 := signature.D.Mul(rR3)
+	T2.Sub(ipk.H0.Mul(rS))
+	T2.Sub(ipk.HSK.Mul(rSK))
+
+	for j := range cred.Attributes {
+		if _, ok := signature.Disclosed[j]; ok {
+			continue
+		}
+
+		rAttributes[j] = amcl_utils.RandomBig(rng)
+		T2.Sub(ipk.H[j].Mul(rAttributes[j]))
+	}
+
+	// T3 = Base^rSK
+	T3 := signature.Base.Mul(rSK)
+
+	G, H, err := predicateGenerators()
+	if err != nil {
+		return nil, err
+	}
+
+	// TC = G^rM * H^rR for each predicate
+	var witnesses []*predicateWitness
+	var TC []*FP256BN.ECP
+
+	for i := range predicates {
+		pred := &predicates[i]
+
+		rM, ok := rAttributes[pred.Index]
+		if !ok {
+			return nil, fmt.Errorf("predicate on attribute %v which is not hidden", pred.Index)
+		}
+
+		proof, witness, err := commitPredicate(pred, cred.Attributes[pred.Index], G, H, rng)
+		if err != nil {
+			return nil, err
+		}
+
+		T := G.Mul(rM)
+		T.Add(H.Mul(witness.rR))
+
+		signature.Predicates = append(signature.Predicates, proof)
+		witnesses = append(witnesses, witness)
+		TC = append(TC, T)
+	}
+
+	cDash := attributeChallenge(&signature, T1, T2, T3, TC, G, basename, message)
+
+	n := amcl_utils.RandomBig(rng)
+	c := finalChallenge(n, cDash)
+
+	signature.SmallC = c
+	signature.SmallN = n
+
+	signature.ZE = schnorrResponse(rE, c, cred.E)
+	signature.ZR2 = schnorrResponse(rR2, c, r2)
+	signature.ZR3 = schnorrResponse(rR3, c, r3)
+	signature.ZS = schnorrResponse(rS, c, sPrime)
+	signature.ZSK = schnorrResponse(rSK, c, signer.sk)
+
+	signature.ZAttributes = map[int]*FP256BN.BIG{}
+
+	for j, r := range rAttributes {
+		signature.ZAttributes[j] = schnorrResponse(r, c, cred.Attributes[j])
+	}
+
+	for i, proof := range signature.Predicates {
+		proof.prove(witnesses[i], c, G, H, rng)
+	}
+
+	return &signature, nil
+}
+
+/**
+ * Verify the signature with attribute credential,
+ * and return the disclosed attributes.
+ */
+func VerifyAttributes(message, basename []byte, signature *AttributeSignature, ipk *AttributeIPK, rl RevocationList) (map[int]*FP256BN.BIG, error) {
+	err := checkAttributeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	count := len(signature.Disclosed) + len(signature.ZAttributes)
+
+	if count > len(ipk.H) {
+		return nil, verifyError(ErrMalformed, "too many attributes: %v > %v", count, len(ipk.H))
+	}
+
+	for i := 0; i < count; i++ {
+		_, isDisclosed := signature.Disclosed[i]
+		_, isHidden := signature.ZAttributes[i]
+
+		if isDisclosed == isHidden {
+			return nil, verifyError(ErrMalformed, "attribute %v must be either disclosed or hidden", i)
+		}
+	}
+
+	if basename != nil {
+		B, err := basenamePoint(basename)
+		if err != nil {
+			return nil, err
+		}
+
+		if !B.Equals(signature.Base) {
+			return nil, verifyError(ErrBasenameMismatch, "Base is not of the basename")
+		}
+	}
+
+	// e(APrime, W) = e(ABar, g2)
+	if !pairingEquals(ipk.W, signature.APrime, amcl_utils.G2(), signature.ABar) {
+		return nil, verifyError(ErrCredentialInvalid, "Ate(W, APrime) != Ate(g2(), ABar)")
+	}
+
+	c := signature.SmallC
+
+	// T1 = APrime^-zE * H0^zR2 * (ABar / D)^-c
+	T1 := negECP(signature.APrime).Mul(signature.ZE)
+	T1.Add(ipk.H0.Mul(signature.ZR2))
+
+	Y1 := FP256BN.NewECP()
+	Y1.Copy(signature.ABar)
+	Y1.Sub(signature.D)
+	T1.Sub(Y1.Mul(c))
+
+	// T2 = D^zR3 * H0^-zS * HSK^-zSK * Π H_j^-zM_j * (g1 * Π H_i^m_i)^-c
+	T2 := signature.D.Mul(signature.ZR3)
+	T2.Sub(ipk.H0.Mul(signature.ZS))
+	T2.Sub(ipk.HSK.Mul(signature.ZSK))
+
+	for _, j := range sortedKeys(signature.ZAttributes) {
+		T2.Sub(ipk.H[j].Mul(signature.ZAttributes[j]))
+	}
+
+	Y2 := amcl_utils.G1()
+
+	for _, i := range sortedKeys(signature.Disclosed) {
+		Y2.Add(ipk.H[i].Mul(signature.Disclosed[i]))
+	}
+
+	T2.Sub(Y2.Mul(c))
+
+	// T3 = Base^zSK * K^-c
+	T3 := signature.Base.Mul(signature.ZSK)
+	T3.Sub(signature.K.Mul(c))
+
+	G, H, err := predicateGenerators()
+	if err != nil {
+		return nil, err
+	}
+
+	// TC = G^zM * H^zR * C^-c for each predicate
+	var TC []*FP256BN.ECP
+
+	for _, proof := range signature.Predicates {
+		err = proof.check()
+		if err != nil {
+			return nil, err
+		}
+
+		zM, ok := signature.ZAttributes[proof.Predicate.Index]
+		if !ok {
+			return nil, verifyError(ErrMalformed, "predicate on attribute %v which is not hidden", proof.Predicate.Index)
+		}
+
+		T := G.Mul(zM)
+		T.Add(H.Mul(proof.ZR))
+		T.Sub(proof.commitment(G).Mul(c))
+
+		TC = append(TC, T)
+	}
+
+	cDash := attributeChallenge(signature, T1, T2, T3, TC, G, basename, message)
+
+	if FP256BN.Comp(c, finalChallenge(signature.SmallN, cDash)) != 0 {
+		return nil, verifyError(ErrProofInvalid, "c is not match")
+	}
+
+	for _, proof := range signature.Predicates {
+		err = proof.verify(c, G, H)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, revoked := range rl {
+		if signature.K.Equals(signature.Base.Mul(revoked)) {
+			return nil, revokedError(ErrKeyRevoked, i)
+		}
+	}
+
+	return signature.Disclosed, nil
+}
+
+/**
+ * Verify the signature with attribute credential which must prove
+ * exactly the given predicates, and return the disclosed attributes.
+ */
+func VerifyAttributesWithPredicates(message, basename []byte, signature *AttributeSignature, ipk *AttributeIPK, predicates []Predicate, rl RevocationList) (map[int]*FP256BN.BIG, error) {
+	err := checkAttributeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(signature.Predicates) != len(predicates) {
+		return nil, verifyError(ErrProofInvalid, "predicates are not match: %v != %v", len(signature.Predicates), len(predicates))
+	}
+
+	for i := range predicates {
+		if !signature.Predicates[i].Predicate.Equals(&predicates[i]) {
+			return nil, verifyError(ErrProofInvalid, "predicate %v is not match", i)
+		}
+	}
+
+	return VerifyAttributes(message, basename, signature, ipk, rl)
+}
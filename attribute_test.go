@@ -0,0 +1,165 @@
+package ecdaa
+
+import (
+	"testing"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func exampleAttributeInitialize(t *testing.T, rng *core.RAND) (*AttributeIssuer, *AttributeSigner, *FP256BN.BIG) {
+	issuer, err := RandomAttributeIssuer(3, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	err = VerifyAttributeIPK(&issuer.Ipk)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	nonce := []byte("nonce")
+
+	req, sk, err := GenAttributeJoinReq(nonce, &issuer.Ipk, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	err = VerifyAttributeJoinReq(req, nonce, &issuer.Ipk)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	attributes := []*FP256BN.BIG{
+		AttributeFromBytes([]byte("model-x")),
+		AttributeFromUint64(42),
+		AttributeFromBytes([]byte("jp")),
+	}
+
+	cred, err := issuer.MakeCred(req, attributes, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	err = VerifyAttributeCred(cred, req.Q, &issuer.Ipk)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	signer := NewAttributeSigner(cred, sk, &issuer.Ipk)
+
+	return &issuer, &signer, sk
+}
+
+func TestAttributeSignAndVerify(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+
+	issuer, signer, sk := exampleAttributeInitialize(t, rng)
+
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	signature, err := signer.Sign(message, basename, []int{1}, rng)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	t.Run("verify_correct", func(t *testing.T) {
+		disclosed, err := VerifyAttributes(message, basename, signature, &issuer.Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+
+		if len(disclosed) != 1 || FP256BN.Comp(disclosed[1], AttributeFromUint64(42)) != 0 {
+			t.Fatalf("disclosed attributes are wrong: %v", disclosed)
+		}
+	})
+
+	t.Run("verify_msg_incorrect", func(t *testing.T) {
+		_, err := VerifyAttributes([]byte("hoge2"), basename, signature, &issuer.Ipk, RevocationList{})
+		if err == nil {
+			t.Fatalf("verify: msg is incorrect but verify say valid")
+		}
+	})
+
+	t.Run("verify_disclosed_modified", func(t *testing.T) {
+		encoded, err := signature.Encode()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var modified AttributeSignature
+		err = modified.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		modified.Disclosed[1] = AttributeFromUint64(43)
+
+		_, err = VerifyAttributes(message, basename, &modified, &issuer.Ipk, RevocationList{})
+		if err == nil {
+			t.Fatalf("verify: disclosed attribute is modified but verify say valid")
+		}
+	})
+
+	t.Run("verify_revoked", func(t *testing.T) {
+		_, err := VerifyAttributes(message, basename, signature, &issuer.Ipk, RevocationList{sk})
+		if err == nil {
+			t.Fatalf("verify: revoked but verify say valid")
+		}
+	})
+
+	t.Run("verify_unlinkable", func(t *testing.T) {
+		unlinkable, err := signer.Sign(message, nil, []int{}, rng)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+
+		disclosed, err := VerifyAttributes(message, nil, unlinkable, &issuer.Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+
+		if len(disclosed) != 0 {
+			t.Fatalf("disclosed attributes are wrong: %v", disclosed)
+		}
+	})
+	t.Run("verify_basename_incorrect", func(t *testing.T) {
+		_, err := VerifyAttributes(message, []byte("fuga2"), signature, &issuer.Ipk, RevocationList{})
+		expectReason(t, err, ErrBasenameMismatch, -1)
+	})
+
+	t.Run("verify_other_issuer", func(t *testing.T) {
+		other, err := RandomAttributeIssuer(3, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = VerifyAttributes(message, basename, signature, &other.Ipk, RevocationList{})
+		expectReason(t, err, ErrCredentialInvalid, -1)
+	})
+
+	t.Run("verify_malformed", func(t *testing.T) {
+		_, err := VerifyAttributes(message, basename, nil, &issuer.Ipk, RevocationList{})
+		expectReason(t, err, ErrMalformed, -1)
+
+		malformed := *signature
+		malformed.APrime = FP256BN.NewECP()
+
+		_, err = VerifyAttributes(message, basename, &malformed, &issuer.Ipk, RevocationList{})
+		expectReason(t, err, ErrMalformed, -1)
+
+		malformed = *signature
+		malformed.ZE = nil
+
+		_, err = VerifyAttributes(message, basename, &malformed, &issuer.Ipk, RevocationList{})
+		expectReason(t, err, ErrMalformed, -1)
+
+		malformed = *signature
+		malformed.Predicates = []*PredicateProof{nil}
+
+		_, err = VerifyAttributesWithPredicates(message, basename, &malformed, &issuer.Ipk, []Predicate{{}}, RevocationList{})
+		expectReason(t, err, ErrMalformed, -1)
+	})
+}
@@ -0,0 +1,201 @@
+package ecdaa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/google/go-tpm/tpm2"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+/**
+ * Declarative measured-boot policy evaluated on the event log at join.
+ * The digests are SHA-256, and an empty list allows anything.
+ *
+ * The event log is trusted only after its replay matches the quoted PCRs,
+ * so the PCRs the policy looks at (0, 4, 7) must be quoted.
+ */
+type BootPolicy struct {
+	// firmware measured in PCR 0 (S-CRTM, POST code, firmware blobs)
+	FirmwareDigests [][]byte
+	// UEFI SecureBoot variable measured in PCR 7 must be enabled
+	RequireSecureBoot bool
+	// EFI applications (bootloaders) loaded and measured in PCR 4
+	BootloaderDigests [][]byte
+}
+
+func containsDigest(digests [][]byte, digest []byte) bool {
+	for _, d := range digests {
+		if bytes.Equal(d, digest) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func requireQuoted(pcrs map[uint][]byte, pcr uint) error {
+	if _, ok := pcrs[pcr]; !ok {
+		return fmt.Errorf("PCR %v is not quoted", pcr)
+	}
+
+	return nil
+}
+
+func isFirmwareEvent(eventType uint32) bool {
+	switch eventType {
+	case tpm_utils.EV_S_CRTM_VERSION,
+		tpm_utils.EV_S_CRTM_CONTENTS,
+		tpm_utils.EV_POST_CODE,
+		tpm_utils.EV_EFI_PLATFORM_FIRMWARE_BLOB,
+		tpm_utils.EV_EFI_PLATFORM_FIRMWARE_BLOB2:
+		return true
+	}
+
+	return false
+}
+
+/**
+ * Whether the SecureBoot variable measured in PCR 7 is enabled.
+ * The replay covers only the digests, so the data of the events
+ * is checked against their SHA-256 digest before it is parsed.
+ */
+func SecureBootEnabled(log *tpm_utils.EventLog) (bool, error) {
+	found := false
+	enabled := false
+
+	for _, event := range log.EventsOf(7) {
+		if event.Type != tpm_utils.EV_EFI_VARIABLE_DRIVER_CONFIG {
+			continue
+		}
+
+		digest := sha256.Sum256(event.Data)
+		if !bytes.Equal(event.Digests[tpm2.TPMAlgSHA256], digest[:]) {
+			return false, fmt.Errorf("EFI variable does not match its digest")
+		}
+
+		variable, err := tpm_utils.ParseEFIVariable(event.Data)
+		if err != nil {
+			return false, err
+		}
+
+		if variable.VariableName != tpm_utils.EFIGlobalVariable || variable.UnicodeName != "SecureBoot" {
+			continue
+		}
+
+		found = true
+		enabled = len(variable.Data) == 1 && variable.Data[0] == 1
+	}
+
+	if !found {
+		return false, fmt.Errorf("SecureBoot variable is not measured")
+	}
+
+	return enabled, nil
+}
+
+func (policy *BootPolicy) checkFirmware(log *tpm_utils.EventLog) error {
+	measured := false
+
+	for _, event := range log.EventsOf(0) {
+		if !isFirmwareEvent(event.Type) {
+			continue
+		}
+
+		digest := event.Digests[tpm2.TPMAlgSHA256]
+
+		if !containsDigest(policy.FirmwareDigests, digest) {
+			return fmt.Errorf("firmware %x is not allowed", digest)
+		}
+
+		measured = true
+	}
+
+	if !measured {
+		return fmt.Errorf("firmware is not measured")
+	}
+
+	return nil
+}
+
+func (policy *BootPolicy) checkBootloaders(log *tpm_utils.EventLog) error {
+	loaded := false
+
+	for _, event := range log.EventsOf(4) {
+		if event.Type != tpm_utils.EV_EFI_BOOT_SERVICES_APPLICATION {
+			continue
+		}
+
+		digest := event.Digests[tpm2.TPMAlgSHA256]
+
+		if !containsDigest(policy.BootloaderDigests, digest) {
+			return fmt.Errorf("bootloader %x is not allowed", digest)
+		}
+
+		loaded = true
+	}
+
+	if !loaded {
+		return fmt.Errorf("bootloader is not measured")
+	}
+
+	return nil
+}
+
+/**
+ * Parse the event log, replay it against the quoted SHA-256 PCRs
+ * and evaluate the policy, for QuoteVerifier.
+ */
+func (policy *BootPolicy) VerifyEventLog(eventLog []byte, pcrs map[uint][]byte) error {
+	log, err := tpm_utils.ParseEventLog(eventLog)
+	if err != nil {
+		return err
+	}
+
+	err = log.VerifyReplay(tpm2.TPMAlgSHA256, pcrs)
+	if err != nil {
+		return err
+	}
+
+	if len(policy.FirmwareDigests) != 0 {
+		err = requireQuoted(pcrs, 0)
+		if err == nil {
+			err = policy.checkFirmware(log)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if policy.RequireSecureBoot {
+		err = requireQuoted(pcrs, 7)
+		if err != nil {
+			return err
+		}
+
+		enabled, err := SecureBootEnabled(log)
+		if err != nil {
+			return err
+		}
+
+		if !enabled {
+			return fmt.Errorf("secure boot is not enabled")
+		}
+	}
+
+	if len(policy.BootloaderDigests) != 0 {
+		err = requireQuoted(pcrs, 4)
+		if err == nil {
+			err = policy.checkBootloaders(log)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package ecdaa
+
+import (
+	"crypto/sha256"
+	"os"
+	"testing"
+
+	"github.com/google/go-tpm/tpm2"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+// event log fixture and its PCR values as quoted
+func readTestEventLog(t *testing.T, name string) ([]byte, *tpm_utils.EventLog, map[uint][]byte) {
+	data, err := os.ReadFile("tpm_utils/testdata/" + name)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	log, err := tpm_utils.ParseEventLog(data)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	pcrs, err := log.Replay(tpm2.TPMAlgSHA256)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return data, log, pcrs
+}
+
+func testDigests(log *tpm_utils.EventLog, pcr uint, eventType uint32) [][]byte {
+	digests := [][]byte{}
+
+	for _, event := range log.EventsOf(pcr) {
+		if event.Type == eventType {
+			digests = append(digests, event.Digests[tpm2.TPMAlgSHA256])
+		}
+	}
+
+	return digests
+}
+
+func TestBootPolicy(t *testing.T) {
+	data, log, pcrs := readTestEventLog(t, "sb_cert_eventlog")
+
+	policy := BootPolicy{
+		FirmwareDigests:   testDigests(log, 0, tpm_utils.EV_EFI_PLATFORM_FIRMWARE_BLOB),
+		RequireSecureBoot: true,
+		BootloaderDigests: testDigests(log, 4, tpm_utils.EV_EFI_BOOT_SERVICES_APPLICATION),
+	}
+
+	err := policy.VerifyEventLog(data, pcrs)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	t.Run("unknown_bootloader", func(t *testing.T) {
+		other := policy
+		other.BootloaderDigests = policy.BootloaderDigests[1:]
+
+		if other.VerifyEventLog(data, pcrs) == nil {
+			t.Fatalf("allowed unknown bootloader")
+		}
+	})
+
+	t.Run("pcr_not_quoted", func(t *testing.T) {
+		partial := map[uint][]byte{0: pcrs[0], 4: pcrs[4]}
+
+		if policy.VerifyEventLog(data, partial) == nil {
+			t.Fatalf("allowed without PCR 7")
+		}
+	})
+
+	t.Run("firmware_stripped", func(t *testing.T) {
+		stripped := *log
+		stripped.Events = []tpm_utils.Event{}
+
+		for _, event := range log.Events {
+			if event.PCR != 0 {
+				stripped.Events = append(stripped.Events, event)
+			}
+		}
+
+		if stripped.VerifyReplay(tpm2.TPMAlgSHA256, pcrs) == nil {
+			t.Fatalf("replay matched log without PCR 0 events")
+		}
+
+		if policy.checkFirmware(&stripped) == nil {
+			t.Fatalf("allowed log without firmware events")
+		}
+	})
+
+	t.Run("replay_mismatch", func(t *testing.T) {
+		wrong := map[uint][]byte{0: pcrs[0], 4: pcrs[4], 7: pcrs[0]}
+
+		if policy.VerifyEventLog(data, wrong) == nil {
+			t.Fatalf("allowed log not matching PCRs")
+		}
+	})
+
+	t.Run("secure_boot_data_rewritten", func(t *testing.T) {
+		_, log, _ := readTestEventLog(t, "ubuntu_2104_shielded_vm_no_secure_boot_eventlog")
+
+		for i, event := range log.Events {
+			variable, err := tpm_utils.ParseEFIVariable(event.Data)
+			if event.Type != tpm_utils.EV_EFI_VARIABLE_DRIVER_CONFIG || err != nil || variable.UnicodeName != "SecureBoot" {
+				continue
+			}
+
+			data := append([]byte{}, event.Data...)
+			data[len(data)-1] = 1
+			log.Events[i].Data = data
+		}
+
+		enabled, err := SecureBootEnabled(log)
+		if err == nil || enabled {
+			t.Fatalf("accepted data not matching the digest")
+		}
+	})
+
+	t.Run("secure_boot_other_guid", func(t *testing.T) {
+		_, log, _ := readTestEventLog(t, "sb_cert_eventlog")
+
+		for i, event := range log.Events {
+			variable, err := tpm_utils.ParseEFIVariable(event.Data)
+			if event.Type != tpm_utils.EV_EFI_VARIABLE_DRIVER_CONFIG || err != nil || variable.UnicodeName != "SecureBoot" {
+				continue
+			}
+
+			data := append([]byte{}, event.Data...)
+			data[0] ^= 0xff
+			digest := sha256.Sum256(data)
+
+			log.Events[i].Data = data
+			log.Events[i].Digests = map[tpm2.TPMAlgID][]byte{tpm2.TPMAlgSHA256: digest[:]}
+		}
+
+		_, err := SecureBootEnabled(log)
+		if err == nil {
+			t.Fatalf("accepted SecureBoot of another vendor")
+		}
+	})
+
+	t.Run("secure_boot_disabled", func(t *testing.T) {
+		data, _, pcrs := readTestEventLog(t, "ubuntu_2104_shielded_vm_no_secure_boot_eventlog")
+		policy := BootPolicy{RequireSecureBoot: true}
+
+		if policy.VerifyEventLog(data, pcrs) == nil {
+			t.Fatalf("allowed without secure boot")
+		}
+
+		policy.RequireSecureBoot = false
+
+		err := policy.VerifyEventLog(data, pcrs)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+}
@@ -87,6 +87,16 @@ func (issuer *Issuer) MakeCredEncrypted(req *JoinRequestTPM, B *FP256BN.ECP, rng
 }
 
 func VerifyCred(cred *Credential, ipk *IPK) error {
+	err := checkCredential(cred)
+	if err != nil {
+		return err
+	}
+
+	err = checkIPK(ipk)
+	if err != nil {
+		return err
+	}
+
 	tmp := FP256BN.NewECP()
 	tmp.Copy(cred.A)
 	tmp.Add(cred.D)
@@ -98,7 +108,7 @@ func VerifyCred(cred *Credential, ipk *IPK) error {
 	b = FP256BN.Fexp(b)
 
 	if !a.Equals(b) {
-		return fmt.Errorf("Ate(ipk.Y, cred.A) != Ate(g2(), cred.B)")
+		return verifyError(ErrCredentialInvalid, "Ate(ipk.Y, cred.A) != Ate(g2(), cred.B)")
 	}
 
 	c := FP256BN.Ate(amcl_utils.G2(), cred.C)
@@ -108,7 +118,7 @@ func VerifyCred(cred *Credential, ipk *IPK) error {
 	d = FP256BN.Fexp(d)
 
 	if !c.Equals(d) {
-		return fmt.Errorf("Ate(g2(), cred.C) != Ate(ipk.X, tmp)")
+		return verifyError(ErrCredentialInvalid, "Ate(g2(), cred.C) != Ate(ipk.X, tmp)")
 	}
 
 	return nil
@@ -123,7 +133,12 @@ func ActivateCredential(
 	ipk *IPK,
 	handle *KeyHandles,
 	tpm *tpm_utils.TPM) (*Credential, error) {
-	secret, err := (*tpm).ActivateCredential(handle.EkHandle, handle.SrkHandle, encCred.IdObject, encCred.WrappedCredential)
+	bound := handle.SrkHandle
+	if handle.AkHandle != nil {
+		bound = handle.AkHandle
+	}
+
+	secret, err := (*tpm).ActivateCredential(handle.EkHandle, bound, encCred.IdObject, encCred.WrappedCredential)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,173 @@
+package ecdaa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * Signing of message digests, for the messages hashed by the callers.
+ *
+ * The signed message is the digest prefixed with a label and the hash
+ * algorithm, so a signature on a digest never verifies as a signature
+ * on the raw message of the same bytes, nor of the other hash algorithm.
+ */
+
+const digestLabel = "ECDAA-DIGEST"
+
+func digestMessage(digest []byte, hash crypto.Hash) ([]byte, error) {
+	if !hash.Available() {
+		return nil, fmt.Errorf("hash %v is not available", hash)
+	}
+
+	if len(digest) != hash.Size() {
+		return nil, fmt.Errorf("digest size %v is not of %v", len(digest), hash)
+	}
+
+	message := append([]byte(digestLabel), byte(hash))
+
+	return append(message, digest...), nil
+}
+
+func SignDigest(signer Signer, digest []byte, hash crypto.Hash, basename []byte, rng *core.RAND) (*Signature, error) {
+	message, err := digestMessage(digest, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer.Sign(message, basename, rng)
+}
+
+func VerifyDigest(digest []byte, hash crypto.Hash, basename []byte, signature *Signature, ipk *IPK, rl RevocationList) error {
+	message, err := digestMessage(digest, hash)
+	if err != nil {
+		return err
+	}
+
+	return Verify(message, basename, signature, ipk, rl)
+}
+
+func readDigest(r io.Reader, hash crypto.Hash) ([]byte, error) {
+	if !hash.Available() {
+		return nil, fmt.Errorf("hash %v is not available", hash)
+	}
+
+	h := hash.New()
+
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+/**
+ * Sign the message streamed from the reader without buffering it.
+ */
+func SignReader(signer Signer, r io.Reader, hash crypto.Hash, basename []byte, rng *core.RAND) (*Signature, error) {
+	digest, err := readDigest(r, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return SignDigest(signer, digest, hash, basename, rng)
+}
+
+func VerifyReader(r io.Reader, hash crypto.Hash, basename []byte, signature *Signature, ipk *IPK, rl RevocationList) error {
+	digest, err := readDigest(r, hash)
+	if err != nil {
+		return err
+	}
+
+	return VerifyDigest(digest, hash, basename, signature, ipk, rl)
+}
+
+/**
+ * Adapter of the signer to crypto.Signer.
+ *
+ * Sign returns the encoded Signature on the basename. The public key is the
+ * IPK, since the signatures are verified with the key of the issuer.
+ * If opts.HashFunc() is 0, the message is signed as is (like ed25519).
+ */
+type CryptoSigner struct {
+	signer   Signer
+	ipk      *IPK
+	basename []byte
+}
+
+func NewCryptoSigner(signer Signer, ipk *IPK, basename []byte) *CryptoSigner {
+	return &CryptoSigner{
+		signer:   signer,
+		ipk:      ipk,
+		basename: basename,
+	}
+}
+
+func (signer *CryptoSigner) Public() crypto.PublicKey {
+	return signer.ipk
+}
+
+// seed core.RAND with the random source given to crypto.Signer,
+// or with crypto/rand if none is given
+func randFromReader(r io.Reader) (*core.RAND, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	seed := make([]byte, amcl_utils.SEED_SIZE)
+
+	_, err := io.ReadFull(r, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	rng := core.NewRAND()
+	rng.Seed(len(seed), seed)
+
+	return rng, nil
+}
+
+func (signer *CryptoSigner) Sign(r io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	rng, err := randFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var signature *Signature
+
+	if opts.HashFunc() == 0 {
+		signature, err = signer.signer.Sign(digest, signer.basename, rng)
+	} else {
+		signature, err = SignDigest(signer.signer, digest, opts.HashFunc(), signer.basename, rng)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return signature.Encode()
+}
+
+/**
+ * Verify the signature made by CryptoSigner.
+ */
+func VerifyCryptoSignature(ipk *IPK, basename, digest, encoded []byte, opts crypto.SignerOpts, rl RevocationList) error {
+	var signature Signature
+
+	err := signature.Decode(encoded)
+	if err != nil {
+		return err
+	}
+
+	if opts.HashFunc() == 0 {
+		return Verify(digest, basename, &signature, ipk, rl)
+	}
+
+	return VerifyDigest(digest, opts.HashFunc(), basename, &signature, ipk, rl)
+}
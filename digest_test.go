@@ -0,0 +1,96 @@
+package ecdaa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestSignDigest(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	message := bytes.Repeat([]byte("hoge"), 1<<16)
+	basename := []byte("fuga")
+
+	issuer, signer, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	digest := sha256.Sum256(message)
+
+	t.Run("digest", func(t *testing.T) {
+		signature, err := SignReader(signer, bytes.NewReader(message), crypto.SHA256, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = VerifyDigest(digest[:], crypto.SHA256, basename, signature, &issuer.Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = VerifyReader(bytes.NewReader(message), crypto.SHA256, basename, signature, &issuer.Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if Verify(digest[:], basename, signature, &issuer.Ipk, RevocationList{}) == nil {
+			t.Fatalf("signature on digest is verified as on raw message")
+		}
+
+		if VerifyDigest(digest[:], crypto.SHA512_256, basename, signature, &issuer.Ipk, RevocationList{}) == nil {
+			t.Fatalf("signature is verified with other hash")
+		}
+
+		_, err = SignDigest(signer, digest[:16], crypto.SHA256, basename, rng)
+		if err == nil {
+			t.Fatalf("short digest is signed")
+		}
+	})
+
+	t.Run("crypto_signer", func(t *testing.T) {
+		var cryptoSigner crypto.Signer = NewCryptoSigner(signer, &issuer.Ipk, basename)
+
+		if cryptoSigner.Public().(*IPK) != &issuer.Ipk {
+			t.Fatalf("public key is not the IPK")
+		}
+
+		encoded, err := cryptoSigner.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = VerifyCryptoSignature(&issuer.Ipk, basename, digest[:], encoded, crypto.SHA256, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		encoded, err = cryptoSigner.Sign(rand.Reader, message, crypto.Hash(0))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = VerifyCryptoSignature(&issuer.Ipk, basename, message, encoded, crypto.Hash(0), RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+
+	t.Run("crypto_signer_nil_rand", func(t *testing.T) {
+		cryptoSigner := NewCryptoSigner(signer, &issuer.Ipk, basename)
+
+		encoded, err := cryptoSigner.Sign(nil, digest[:], crypto.SHA256)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = VerifyCryptoSignature(&issuer.Ipk, basename, digest[:], encoded, crypto.SHA256, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+}
@@ -5,10 +5,13 @@ import (
 	"crypto/x509"
 	"encoding/gob"
 	"fmt"
+	"time"
 
 	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
 
 	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+
+	"github.com/akakou/ecdaa/tpm_utils"
 )
 
 func Encode[T any](data T) ([]byte, error) {
@@ -143,7 +146,11 @@ func (proof *SchnorrProof) Encode() ([]byte, error) {
 	mid.SmallC = amcl_utils.BigToBytes(proof.SmallC)
 	mid.SmallN = amcl_utils.BigToBytes(proof.SmallN)
 	mid.SmallS = amcl_utils.BigToBytes(proof.SmallS)
-	mid.K = amcl_utils.EcpToBytes(proof.K)
+
+	// the proofs without basename have no K
+	if proof.K != nil {
+		mid.K = amcl_utils.EcpToBytes(proof.K)
+	}
 
 	return Encode(mid)
 }
@@ -159,14 +166,20 @@ func (decoded *SchnorrProof) Decode(encoded []byte) error {
 	decoded.SmallC = FP256BN.FromBytes(mid.SmallC)
 	decoded.SmallS = FP256BN.FromBytes(mid.SmallS)
 	decoded.SmallN = FP256BN.FromBytes(mid.SmallN)
-	decoded.K = FP256BN.ECP_fromBytes(mid.K)
+	decoded.K = nil
+
+	if mid.K != nil {
+		decoded.K = FP256BN.ECP_fromBytes(mid.K)
+	}
 
 	return nil
 }
 
 type MiddleEncodedSignature struct {
-	Credential []byte
-	Proof      []byte
+	Credential  []byte
+	Proof       []byte
+	Epoch       uint32
+	Fingerprint []byte
 }
 
 func (signature *Signature) Encode() ([]byte, error) {
@@ -185,6 +198,9 @@ func (signature *Signature) Encode() ([]byte, error) {
 		return nil, err
 	}
 
+	mid.Epoch = signature.Epoch
+	mid.Fingerprint = signature.Fingerprint
+
 	return Encode(mid)
 }
 
@@ -212,6 +228,340 @@ func (decoded *Signature) Decode(encoded []byte) error {
 
 	decoded.RandomizedCred = &cred
 	decoded.Proof = &proof
+	decoded.Epoch = mid.Epoch
+	decoded.Fingerprint = mid.Fingerprint
+
+	return nil
+}
+
+type MiddleEncodedKTimesSignature struct {
+	Signature []byte
+	Epoch     uint64
+	Slot      uint32
+}
+
+func (signature *KTimesSignature) Encode() ([]byte, error) {
+	var err error
+	var mid MiddleEncodedKTimesSignature
+
+	mid.Signature, err = signature.Signature.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	mid.Epoch = signature.Epoch
+	mid.Slot = signature.Slot
+
+	return Encode(mid)
+}
+
+func (decoded *KTimesSignature) Decode(encoded []byte) error {
+	var mid MiddleEncodedKTimesSignature
+	var signature Signature
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	err = signature.Decode(mid.Signature)
+	if err != nil {
+		return err
+	}
+
+	decoded.Signature = &signature
+	decoded.Epoch = mid.Epoch
+	decoded.Slot = mid.Slot
+
+	return nil
+}
+
+type MiddleEncodedTraceableSignature struct {
+	Signature []byte
+	T1        []byte
+	T2        []byte
+	C         []byte
+	SSK       []byte
+	SR        []byte
+}
+
+func (signature *TraceableSignature) Encode() ([]byte, error) {
+	var err error
+	var mid MiddleEncodedTraceableSignature
+
+	mid.Signature, err = signature.Signature.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	mid.T1 = amcl_utils.EcpToBytes(signature.T1)
+	mid.T2 = amcl_utils.EcpToBytes(signature.T2)
+	mid.C = amcl_utils.BigToBytes(signature.C)
+	mid.SSK = amcl_utils.BigToBytes(signature.SSK)
+	mid.SR = amcl_utils.BigToBytes(signature.SR)
+
+	return Encode(mid)
+}
+
+func (decoded *TraceableSignature) Decode(encoded []byte) error {
+	var mid MiddleEncodedTraceableSignature
+	var signature Signature
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	err = signature.Decode(mid.Signature)
+	if err != nil {
+		return err
+	}
+
+	decoded.Signature = &signature
+	decoded.T1 = FP256BN.ECP_fromBytes(mid.T1)
+	decoded.T2 = FP256BN.ECP_fromBytes(mid.T2)
+	decoded.C = FP256BN.FromBytes(mid.C)
+	decoded.SSK = FP256BN.FromBytes(mid.SSK)
+	decoded.SR = FP256BN.FromBytes(mid.SR)
+
+	return nil
+}
+
+type MiddleEncodedTracingKey struct {
+	P []byte
+	C []byte
+	S []byte
+}
+
+func (key *TracingKey) Encode() ([]byte, error) {
+	var mid MiddleEncodedTracingKey
+
+	mid.P = amcl_utils.EcpToBytes(key.P)
+	mid.C = amcl_utils.BigToBytes(key.C)
+	mid.S = amcl_utils.BigToBytes(key.S)
+
+	return Encode(mid)
+}
+
+func (decoded *TracingKey) Decode(encoded []byte) error {
+	var mid MiddleEncodedTracingKey
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	decoded.P = FP256BN.ECP_fromBytes(mid.P)
+	decoded.C = FP256BN.FromBytes(mid.C)
+	decoded.S = FP256BN.FromBytes(mid.S)
+
+	return nil
+}
+
+type MiddleEncodedJoinRecord struct {
+	ID            uint64
+	Q             []byte
+	EKFingerprint []byte
+	SrkName       []byte
+	IssuedAt      int64
+	Epoch         uint32
+	Accepted      bool
+	Decision      string
+	Revoked       bool
+	RevokedAt     int64
+}
+
+func (record *JoinRecord) Encode() ([]byte, error) {
+	mid := MiddleEncodedJoinRecord{
+		ID:            record.ID,
+		Q:             amcl_utils.EcpToBytes(record.Q),
+		EKFingerprint: record.EKFingerprint,
+		SrkName:       record.SrkName,
+		IssuedAt:      record.IssuedAt.UnixNano(),
+		Epoch:         record.Epoch,
+		Accepted:      record.Accepted,
+		Decision:      record.Decision,
+		Revoked:       record.Revoked,
+	}
+
+	if record.Revoked {
+		mid.RevokedAt = record.RevokedAt.UnixNano()
+	}
+
+	return Encode(mid)
+}
+
+func (decoded *JoinRecord) Decode(encoded []byte) error {
+	var mid MiddleEncodedJoinRecord
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	decoded.ID = mid.ID
+	decoded.Q = FP256BN.ECP_fromBytes(mid.Q)
+	decoded.EKFingerprint = mid.EKFingerprint
+	decoded.SrkName = mid.SrkName
+	decoded.IssuedAt = time.Unix(0, mid.IssuedAt)
+	decoded.Epoch = mid.Epoch
+	decoded.Accepted = mid.Accepted
+	decoded.Decision = mid.Decision
+	decoded.Revoked = mid.Revoked
+	decoded.RevokedAt = time.Time{}
+
+	if mid.Revoked {
+		decoded.RevokedAt = time.Unix(0, mid.RevokedAt)
+	}
+
+	return nil
+}
+
+type MiddleEncodedIssuerEpoch struct {
+	ID        uint32
+	Ipk       []byte
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+func (set *IPKSet) Encode() ([]byte, error) {
+	var mid []MiddleEncodedIssuerEpoch
+
+	for _, epoch := range set.Epochs {
+		ipk, err := epoch.Ipk.Encode()
+		if err != nil {
+			return nil, err
+		}
+
+		mid = append(mid, MiddleEncodedIssuerEpoch{
+			ID:        epoch.ID,
+			Ipk:       ipk,
+			NotBefore: epoch.NotBefore,
+			NotAfter:  epoch.NotAfter,
+		})
+	}
+
+	return Encode(mid)
+}
+
+func (decoded *IPKSet) Decode(encoded []byte) error {
+	var mid []MiddleEncodedIssuerEpoch
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	decoded.Epochs = nil
+
+	for _, epoch := range mid {
+		var ipk IPK
+
+		err = ipk.Decode(epoch.Ipk)
+		if err != nil {
+			return err
+		}
+
+		decoded.Epochs = append(decoded.Epochs, IssuerEpoch{
+			ID:        epoch.ID,
+			Ipk:       ipk,
+			NotBefore: epoch.NotBefore,
+			NotAfter:  epoch.NotAfter,
+		})
+	}
+
+	return nil
+}
+
+type MiddleEncodedSWSigner struct {
+	Credential []byte
+	SK         []byte
+}
+
+func (signer *SWSigner) Encode() ([]byte, error) {
+	var err error
+	var mid MiddleEncodedSWSigner
+
+	mid.Credential, err = signer.cred.Encode()
+
+	if err != nil {
+		return nil, err
+	}
+
+	mid.SK = amcl_utils.BigToBytes(signer.sk)
+
+	return Encode(mid)
+}
+
+func (decoded *SWSigner) Decode(encoded []byte) error {
+	var mid MiddleEncodedSWSigner
+	var cred Credential
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	err = cred.Decode(mid.Credential)
+
+	if err != nil {
+		return err
+	}
+
+	decoded.cred = &cred
+	decoded.sk = FP256BN.FromBytes(mid.SK)
+
+	return nil
+}
+
+type MiddleEncodedSealedSigner struct {
+	Credential []byte
+	Public     []byte
+	Private    []byte
+	PCRs       []uint
+}
+
+func (signer *SealedSigner) Encode() ([]byte, error) {
+	var err error
+	var mid MiddleEncodedSealedSigner
+
+	mid.Credential, err = signer.cred.Encode()
+
+	if err != nil {
+		return nil, err
+	}
+
+	mid.Public = signer.blob.Public
+	mid.Private = signer.blob.Private
+	mid.PCRs = signer.blob.PCRs
+
+	return Encode(mid)
+}
+
+/**
+ * Decode the sealed signer, whose TPM must be set with SetTPM before signing.
+ */
+func (decoded *SealedSigner) Decode(encoded []byte) error {
+	var mid MiddleEncodedSealedSigner
+	var cred Credential
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	err = cred.Decode(mid.Credential)
+
+	if err != nil {
+		return err
+	}
+
+	decoded.cred = &cred
+	decoded.blob = &tpm_utils.SealedBlob{
+		Public:  mid.Public,
+		Private: mid.Private,
+		PCRs:    mid.PCRs,
+	}
 
 	return nil
 }
@@ -284,9 +634,20 @@ func (decoded *JoinRequest) Decode(encoded []byte) error {
 }
 
 type MiddleEncodedJoinRequestTPM struct {
-	JoinReq []byte
-	EKCert  []byte
-	SrkName []byte
+	JoinReq       []byte
+	EKCert        []byte
+	SrkName       []byte
+	Intermediates [][]byte
+	PlatformCerts [][]byte
+	Quote         *MiddleEncodedJoinQuote
+}
+
+type MiddleEncodedJoinQuote struct {
+	AKPublic  []byte
+	Quoted    []byte
+	Signature []byte
+	PCRs      map[uint][]byte
+	EventLog  []byte
 }
 
 func (request *JoinRequestTPM) Encode() ([]byte, error) {
@@ -295,6 +656,21 @@ func (request *JoinRequestTPM) Encode() ([]byte, error) {
 
 	mid.EKCert = request.EKCert.Raw
 	mid.SrkName = request.SrkName
+	mid.PlatformCerts = request.Bundle.PlatformCerts
+
+	for _, cert := range request.Bundle.Intermediates {
+		mid.Intermediates = append(mid.Intermediates, cert.Raw)
+	}
+
+	if request.Quote != nil {
+		mid.Quote = &MiddleEncodedJoinQuote{
+			AKPublic:  request.Quote.AKPublic,
+			Quoted:    request.Quote.Quote.Quoted,
+			Signature: request.Quote.Quote.Signature,
+			PCRs:      request.Quote.Quote.PCRs,
+			EventLog:  request.Quote.EventLog,
+		}
+	}
 	mid.JoinReq, err = request.JoinReq.Encode()
 
 	if err != nil {
@@ -333,6 +709,33 @@ func (decoded *JoinRequestTPM) Decode(encoded []byte) error {
 		return err
 	}
 
+	decoded.Bundle.PlatformCerts = mid.PlatformCerts
+	decoded.Bundle.Intermediates = nil
+
+	for _, raw := range mid.Intermediates {
+		cert, err := x509.ParseCertificate(raw)
+
+		if err != nil {
+			return err
+		}
+
+		decoded.Bundle.Intermediates = append(decoded.Bundle.Intermediates, cert)
+	}
+
+	decoded.Quote = nil
+
+	if mid.Quote != nil {
+		decoded.Quote = &JoinQuote{
+			AKPublic: mid.Quote.AKPublic,
+			Quote: tpm_utils.Quote{
+				Quoted:    mid.Quote.Quoted,
+				Signature: mid.Quote.Signature,
+				PCRs:      mid.Quote.PCRs,
+			},
+			EventLog: mid.Quote.EventLog,
+		}
+	}
+
 	return nil
 
 }
@@ -374,3 +777,545 @@ func DecodeRevocationList(list [][]byte) RevocationList {
 
 	return result
 }
+
+type MiddleEncodedSignatureRevocation struct {
+	Basename []byte
+	K        []byte
+}
+
+type MiddleEncodedSignedRevocationList struct {
+	Sequence     uint64
+	BaseSequence uint64
+	IssuedAt     int64
+	NextUpdate   int64
+	Keys         [][]byte
+	Signatures   []MiddleEncodedSignatureRevocation
+	Signature    []byte
+}
+
+func (list *SignedRevocationList) Encode() ([]byte, error) {
+	mid := MiddleEncodedSignedRevocationList{
+		Sequence:     list.Sequence,
+		BaseSequence: list.BaseSequence,
+		IssuedAt:     list.IssuedAt.UnixNano(),
+		NextUpdate:   list.NextUpdate.UnixNano(),
+		Keys:         EncodeRevocationList(list.Keys),
+		Signature:    list.Signature,
+	}
+
+	for _, entry := range list.Signatures {
+		mid.Signatures = append(mid.Signatures, MiddleEncodedSignatureRevocation{
+			Basename: entry.Basename,
+			K:        amcl_utils.EcpToBytes(entry.K),
+		})
+	}
+
+	return Encode(mid)
+}
+
+func (decoded *SignedRevocationList) Decode(encoded []byte) error {
+	var mid MiddleEncodedSignedRevocationList
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	decoded.Sequence = mid.Sequence
+	decoded.BaseSequence = mid.BaseSequence
+	decoded.IssuedAt = time.Unix(0, mid.IssuedAt)
+	decoded.NextUpdate = time.Unix(0, mid.NextUpdate)
+	decoded.Keys = DecodeRevocationList(mid.Keys)
+	decoded.Signatures = nil
+	decoded.Signature = mid.Signature
+
+	for _, entry := range mid.Signatures {
+		decoded.Signatures = append(decoded.Signatures, SignatureRevocation{
+			Basename: entry.Basename,
+			K:        FP256BN.ECP_fromBytes(entry.K),
+		})
+	}
+
+	return nil
+}
+
+func encodeBIGMap(m map[int]*FP256BN.BIG) map[int][]byte {
+	result := map[int][]byte{}
+
+	for k, v := range m {
+		result[k] = amcl_utils.BigToBytes(v)
+	}
+
+	return result
+}
+
+func decodeBIGMap(m map[int][]byte) map[int]*FP256BN.BIG {
+	result := map[int]*FP256BN.BIG{}
+
+	for k, v := range m {
+		result[k] = FP256BN.FromBytes(v)
+	}
+
+	return result
+}
+
+type MiddleEncodedAttributeIPK struct {
+	W   []byte
+	H0  []byte
+	HSK []byte
+	H   [][]byte
+	C   []byte
+	S   []byte
+}
+
+func (ipk *AttributeIPK) Encode() ([]byte, error) {
+	var mid MiddleEncodedAttributeIPK
+
+	mid.W = amcl_utils.Ecp2ToBytes(ipk.W)
+	mid.H0 = amcl_utils.EcpToBytes(ipk.H0)
+	mid.HSK = amcl_utils.EcpToBytes(ipk.HSK)
+	mid.C = amcl_utils.BigToBytes(ipk.C)
+	mid.S = amcl_utils.BigToBytes(ipk.S)
+
+	for _, h := range ipk.H {
+		mid.H = append(mid.H, amcl_utils.EcpToBytes(h))
+	}
+
+	return Encode(mid)
+}
+
+func (decoded *AttributeIPK) Decode(encoded []byte) error {
+	var mid MiddleEncodedAttributeIPK
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	decoded.W = FP256BN.ECP2_fromBytes(mid.W)
+	decoded.H0 = FP256BN.ECP_fromBytes(mid.H0)
+	decoded.HSK = FP256BN.ECP_fromBytes(mid.HSK)
+	decoded.C = FP256BN.FromBytes(mid.C)
+	decoded.S = FP256BN.FromBytes(mid.S)
+	decoded.H = nil
+
+	for _, h := range mid.H {
+		decoded.H = append(decoded.H, FP256BN.ECP_fromBytes(h))
+	}
+
+	return nil
+}
+
+type MiddleEncodedAttributeCredential struct {
+	A          []byte
+	E          []byte
+	S          []byte
+	Attributes [][]byte
+}
+
+func (cred *AttributeCredential) Encode() ([]byte, error) {
+	var mid MiddleEncodedAttributeCredential
+
+	mid.A = amcl_utils.EcpToBytes(cred.A)
+	mid.E = amcl_utils.BigToBytes(cred.E)
+	mid.S = amcl_utils.BigToBytes(cred.S)
+
+	for _, attribute := range cred.Attributes {
+		mid.Attributes = append(mid.Attributes, amcl_utils.BigToBytes(attribute))
+	}
+
+	return Encode(mid)
+}
+
+func (decoded *AttributeCredential) Decode(encoded []byte) error {
+	var mid MiddleEncodedAttributeCredential
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	decoded.A = FP256BN.ECP_fromBytes(mid.A)
+	decoded.E = FP256BN.FromBytes(mid.E)
+	decoded.S = FP256BN.FromBytes(mid.S)
+	decoded.Attributes = nil
+
+	for _, attribute := range mid.Attributes {
+		decoded.Attributes = append(decoded.Attributes, FP256BN.FromBytes(attribute))
+	}
+
+	return nil
+}
+
+type MiddleEncodedAttributeSignature struct {
+	APrime []byte
+	ABar   []byte
+	D      []byte
+	Base   []byte
+	K      []byte
+
+	SmallC []byte
+	SmallN []byte
+
+	ZE  []byte
+	ZR2 []byte
+	ZR3 []byte
+	ZS  []byte
+	ZSK []byte
+
+	Disclosed   map[int][]byte
+	ZAttributes map[int][]byte
+
+	Predicates [][]byte
+}
+
+func (signature *AttributeSignature) Encode() ([]byte, error) {
+	var mid MiddleEncodedAttributeSignature
+
+	mid.APrime = amcl_utils.EcpToBytes(signature.APrime)
+	mid.ABar = amcl_utils.EcpToBytes(signature.ABar)
+	mid.D = amcl_utils.EcpToBytes(signature.D)
+	mid.Base = amcl_utils.EcpToBytes(signature.Base)
+	mid.K = amcl_utils.EcpToBytes(signature.K)
+
+	mid.SmallC = amcl_utils.BigToBytes(signature.SmallC)
+	mid.SmallN = amcl_utils.BigToBytes(signature.SmallN)
+
+	mid.ZE = amcl_utils.BigToBytes(signature.ZE)
+	mid.ZR2 = amcl_utils.BigToBytes(signature.ZR2)
+	mid.ZR3 = amcl_utils.BigToBytes(signature.ZR3)
+	mid.ZS = amcl_utils.BigToBytes(signature.ZS)
+	mid.ZSK = amcl_utils.BigToBytes(signature.ZSK)
+
+	mid.Disclosed = encodeBIGMap(signature.Disclosed)
+	mid.ZAttributes = encodeBIGMap(signature.ZAttributes)
+
+	for _, proof := range signature.Predicates {
+		encoded, err := proof.Encode()
+		if err != nil {
+			return nil, err
+		}
+
+		mid.Predicates = append(mid.Predicates, encoded)
+	}
+
+	return Encode(mid)
+}
+
+func (decoded *AttributeSignature) Decode(encoded []byte) error {
+	var mid MiddleEncodedAttributeSignature
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	decoded.APrime = FP256BN.ECP_fromBytes(mid.APrime)
+	decoded.ABar = FP256BN.ECP_fromBytes(mid.ABar)
+	decoded.D = FP256BN.ECP_fromBytes(mid.D)
+	decoded.Base = FP256BN.ECP_fromBytes(mid.Base)
+	decoded.K = FP256BN.ECP_fromBytes(mid.K)
+
+	decoded.SmallC = FP256BN.FromBytes(mid.SmallC)
+	decoded.SmallN = FP256BN.FromBytes(mid.SmallN)
+
+	decoded.ZE = FP256BN.FromBytes(mid.ZE)
+	decoded.ZR2 = FP256BN.FromBytes(mid.ZR2)
+	decoded.ZR3 = FP256BN.FromBytes(mid.ZR3)
+	decoded.ZS = FP256BN.FromBytes(mid.ZS)
+	decoded.ZSK = FP256BN.FromBytes(mid.ZSK)
+
+	decoded.Disclosed = decodeBIGMap(mid.Disclosed)
+	decoded.ZAttributes = decodeBIGMap(mid.ZAttributes)
+	decoded.Predicates = nil
+
+	for _, encodedProof := range mid.Predicates {
+		var proof PredicateProof
+
+		err = proof.Decode(encodedProof)
+		if err != nil {
+			return err
+		}
+
+		decoded.Predicates = append(decoded.Predicates, &proof)
+	}
+
+	return nil
+}
+
+func encodeBIGs(list []*FP256BN.BIG) [][]byte {
+	var result [][]byte
+
+	for _, v := range list {
+		result = append(result, amcl_utils.BigToBytes(v))
+	}
+
+	return result
+}
+
+func decodeBIGs(list [][]byte) []*FP256BN.BIG {
+	var result []*FP256BN.BIG
+
+	for _, v := range list {
+		result = append(result, FP256BN.FromBytes(v))
+	}
+
+	return result
+}
+
+type MiddleEncodedOrProof struct {
+	C [][]byte
+	Z [][]byte
+}
+
+type MiddleEncodedPredicateProof struct {
+	Index int
+	Type  int
+	Bound []byte
+	Set   [][]byte
+
+	C  []byte
+	ZR []byte
+
+	Bits      [][]byte
+	BitProofs []MiddleEncodedOrProof
+	SetProof  *MiddleEncodedOrProof
+
+	LowerBits      [][]byte
+	LowerBitProofs []MiddleEncodedOrProof
+}
+
+func (proof *PredicateProof) Encode() ([]byte, error) {
+	var mid MiddleEncodedPredicateProof
+
+	mid.Index = proof.Predicate.Index
+	mid.Type = int(proof.Predicate.Type)
+	mid.Set = encodeBIGs(proof.Predicate.Set)
+	mid.ZR = amcl_utils.BigToBytes(proof.ZR)
+
+	if proof.Predicate.Bound != nil {
+		mid.Bound = amcl_utils.BigToBytes(proof.Predicate.Bound)
+	}
+
+	if proof.C != nil {
+		mid.C = amcl_utils.EcpToBytes(proof.C)
+	}
+
+	for _, bit := range proof.Bits {
+		mid.Bits = append(mid.Bits, amcl_utils.EcpToBytes(bit))
+	}
+
+	for _, bitProof := range proof.BitProofs {
+		mid.BitProofs = append(mid.BitProofs, MiddleEncodedOrProof{
+			C: encodeBIGs(bitProof.C),
+			Z: encodeBIGs(bitProof.Z),
+		})
+	}
+
+	for _, bit := range proof.LowerBits {
+		mid.LowerBits = append(mid.LowerBits, amcl_utils.EcpToBytes(bit))
+	}
+
+	for _, bitProof := range proof.LowerBitProofs {
+		mid.LowerBitProofs = append(mid.LowerBitProofs, MiddleEncodedOrProof{
+			C: encodeBIGs(bitProof.C),
+			Z: encodeBIGs(bitProof.Z),
+		})
+	}
+
+	if proof.SetProof != nil {
+		mid.SetProof = &MiddleEncodedOrProof{
+			C: encodeBIGs(proof.SetProof.C),
+			Z: encodeBIGs(proof.SetProof.Z),
+		}
+	}
+
+	return Encode(mid)
+}
+
+func (decoded *PredicateProof) Decode(encoded []byte) error {
+	var mid MiddleEncodedPredicateProof
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	decoded.Predicate = Predicate{
+		Index: mid.Index,
+		Type:  PredicateType(mid.Type),
+		Set:   decodeBIGs(mid.Set),
+	}
+
+	if mid.Bound != nil {
+		decoded.Predicate.Bound = FP256BN.FromBytes(mid.Bound)
+	}
+
+	if mid.C != nil {
+		decoded.C = FP256BN.ECP_fromBytes(mid.C)
+	}
+
+	decoded.ZR = FP256BN.FromBytes(mid.ZR)
+	decoded.Bits = nil
+	decoded.BitProofs = nil
+
+	for _, bit := range mid.Bits {
+		decoded.Bits = append(decoded.Bits, FP256BN.ECP_fromBytes(bit))
+	}
+
+	for _, bitProof := range mid.BitProofs {
+		decoded.BitProofs = append(decoded.BitProofs, &OrProof{
+			C: decodeBIGs(bitProof.C),
+			Z: decodeBIGs(bitProof.Z),
+		})
+	}
+
+	decoded.LowerBits = nil
+	decoded.LowerBitProofs = nil
+
+	for _, bit := range mid.LowerBits {
+		decoded.LowerBits = append(decoded.LowerBits, FP256BN.ECP_fromBytes(bit))
+	}
+
+	for _, bitProof := range mid.LowerBitProofs {
+		decoded.LowerBitProofs = append(decoded.LowerBitProofs, &OrProof{
+			C: decodeBIGs(bitProof.C),
+			Z: decodeBIGs(bitProof.Z),
+		})
+	}
+
+	if mid.SetProof != nil {
+		decoded.SetProof = &OrProof{
+			C: decodeBIGs(mid.SetProof.C),
+			Z: decodeBIGs(mid.SetProof.Z),
+		}
+	}
+
+	return nil
+}
+
+type MiddleEncodedWalletCredential struct {
+	IPK   []byte
+	Cred  []byte
+	Epoch uint32
+	Key   string
+}
+
+type MiddleEncodedWallet struct {
+	Credentials []MiddleEncodedWalletCredential
+}
+
+/**
+ * Encode the credentials of the wallet (without the keys).
+ */
+func (wallet *Wallet) Encode() ([]byte, error) {
+	var mid MiddleEncodedWallet
+
+	for _, id := range wallet.IDs() {
+		credential, err := wallet.Credential(id)
+		if err != nil {
+			return nil, err
+		}
+
+		ipk, err := credential.IPK.Encode()
+		if err != nil {
+			return nil, err
+		}
+
+		cred, err := credential.Cred.Encode()
+		if err != nil {
+			return nil, err
+		}
+
+		mid.Credentials = append(mid.Credentials, MiddleEncodedWalletCredential{
+			IPK:   ipk,
+			Cred:  cred,
+			Epoch: credential.Epoch,
+			Key:   credential.Key,
+		})
+	}
+
+	return Encode(mid)
+}
+
+/**
+ * Decode the credentials into the wallet, whose keys must be added before.
+ */
+func (decoded *Wallet) Decode(encoded []byte) error {
+	var mid MiddleEncodedWallet
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range mid.Credentials {
+		var ipk IPK
+		var cred Credential
+
+		err = ipk.Decode(entry.IPK)
+		if err != nil {
+			return err
+		}
+
+		err = cred.Decode(entry.Cred)
+		if err != nil {
+			return err
+		}
+
+		_, err = decoded.Add(&ipk, &cred, entry.Epoch, entry.Key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type MiddleEncodedTrustedIPK struct {
+	IPK       []byte
+	Group     string
+	NotBefore time.Time
+	NotAfter  time.Time
+	Basenames [][]byte
+}
+
+func (entry *TrustedIPK) Encode() ([]byte, error) {
+	ipk, err := entry.IPK.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	mid := MiddleEncodedTrustedIPK{
+		IPK:       ipk,
+		Group:     entry.Group,
+		NotBefore: entry.NotBefore,
+		NotAfter:  entry.NotAfter,
+		Basenames: entry.Basenames,
+	}
+
+	return Encode(mid)
+}
+
+func (decoded *TrustedIPK) Decode(encoded []byte) error {
+	var mid MiddleEncodedTrustedIPK
+	var ipk IPK
+
+	err := Decode(&mid, encoded)
+	if err != nil {
+		return err
+	}
+
+	err = ipk.Decode(mid.IPK)
+	if err != nil {
+		return err
+	}
+
+	decoded.IPK = &ipk
+	decoded.Group = mid.Group
+	decoded.NotBefore = mid.NotBefore
+	decoded.NotAfter = mid.NotAfter
+	decoded.Basenames = mid.Basenames
+
+	return nil
+}
@@ -334,3 +334,43 @@ func TestEncodedDecodeRL(t *testing.T) {
 		}
 	}
 }
+
+func TestEncodeDecodeSealedSigner(t *testing.T) {
+	rnd := amcl_utils.InitRandom()
+
+	cred := Credential{
+		A: amcl_utils.RandomECP(rnd),
+		B: amcl_utils.RandomECP(rnd),
+		C: amcl_utils.RandomECP(rnd),
+		D: amcl_utils.RandomECP(rnd),
+	}
+
+	blob := tpm_utils.SealedBlob{
+		Public:  []byte("public"),
+		Private: []byte("private"),
+		PCRs:    []uint{0, 7},
+	}
+
+	signer := NewSealedSigner(&cred, &blob, nil)
+
+	encoded, err := signer.Encode()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var decoded SealedSigner
+
+	err = decoded.Decode(encoded)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if !cred.A.Equals(decoded.cred.A) || !bytes.Equal(blob.Private, decoded.blob.Private) || len(decoded.blob.PCRs) != 2 {
+		t.Fatalf("sealed signer is not equal")
+	}
+
+	_, err = decoded.Sign([]byte("hoge"), nil, rnd)
+	if err == nil {
+		t.Fatalf("sealed signer signs without TPM")
+	}
+}
@@ -0,0 +1,148 @@
+package ecdaa
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+)
+
+/**
+ * Issuer epoch: a versioned issuer key pair with validity window.
+ *
+ * Issuer keys are rotated by opening a new epoch, and verifiers
+ * pick the IPK of the epoch carried in the signature.
+ */
+type IssuerEpoch struct {
+	ID        uint32
+	Ipk       IPK
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+/**
+ * The set of IPKs published by the issuer.
+ */
+type IPKSet struct {
+	Epochs []IssuerEpoch
+}
+
+type EpochIssuer struct {
+	Current uint32
+	Issuers map[uint32]*Issuer
+	Set     IPKSet
+}
+
+func (epoch *IssuerEpoch) ValidAt(now time.Time) bool {
+	return !now.Before(epoch.NotBefore) && now.Before(epoch.NotAfter)
+}
+
+/**
+ * Find the epoch by ID and check it is valid at the time.
+ */
+func (set *IPKSet) Lookup(id uint32, now time.Time) (*IssuerEpoch, error) {
+	for i := range set.Epochs {
+		epoch := &set.Epochs[i]
+
+		if epoch.ID != id {
+			continue
+		}
+
+		if !epoch.ValidAt(now) {
+			return nil, fmt.Errorf("epoch %v is not valid at %v", id, now)
+		}
+
+		return epoch, nil
+	}
+
+	return nil, fmt.Errorf("epoch %v is not found", id)
+}
+
+func NewEpochIssuer() EpochIssuer {
+	return EpochIssuer{
+		Issuers: map[uint32]*Issuer{},
+	}
+}
+
+/**
+ * Generate a new issuer key pair and open a new epoch with it.
+ * The previous epochs are kept until their validity windows end,
+ * so the members can re-join under the new epoch at their own pace.
+ */
+func (ei *EpochIssuer) Rotate(notBefore, notAfter time.Time, rng *core.RAND) (*IssuerEpoch, error) {
+	if !notBefore.Before(notAfter) {
+		return nil, fmt.Errorf("invalid validity window: %v - %v", notBefore, notAfter)
+	}
+
+	id := uint32(len(ei.Set.Epochs)) + 1
+	issuer := RandomIssuer(rng)
+
+	epoch := IssuerEpoch{
+		ID:        id,
+		Ipk:       issuer.Ipk,
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+	}
+
+	ei.Issuers[id] = &issuer
+	ei.Set.Epochs = append(ei.Set.Epochs, epoch)
+	ei.Current = id
+
+	return &epoch, nil
+}
+
+/**
+ * Issuer of the epoch, which is used for joining under it.
+ */
+func (ei *EpochIssuer) Issuer(id uint32) (*Issuer, error) {
+	issuer, ok := ei.Issuers[id]
+	if !ok {
+		return nil, fmt.Errorf("epoch %v is not found", id)
+	}
+
+	return issuer, nil
+}
+
+func (ei *EpochIssuer) CurrentIssuer() (*Issuer, error) {
+	return ei.Issuer(ei.Current)
+}
+
+/**
+ * Signer which tags the signatures with the epoch of its credential.
+ */
+type EpochSigner struct {
+	signer Signer
+	epoch  uint32
+}
+
+func NewEpochSigner(signer Signer, epoch uint32) EpochSigner {
+	var epochSigner = EpochSigner{
+		signer: signer,
+		epoch:  epoch,
+	}
+
+	return epochSigner
+}
+
+func (signer EpochSigner) Sign(message, basename []byte, rng *core.RAND) (*Signature, error) {
+	signature, err := signer.signer.Sign(message, basename, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	signature.Epoch = signer.epoch
+
+	return signature, nil
+}
+
+/**
+ * Verify the signature with the IPK of the epoch carried in it.
+ */
+func VerifyWithIPKSet(message, basename []byte, signature *Signature, set *IPKSet, now time.Time, rl RevocationList) error {
+	epoch, err := set.Lookup(signature.Epoch, now)
+	if err != nil {
+		return err
+	}
+
+	return Verify(message, basename, signature, &epoch.Ipk, rl)
+}
@@ -0,0 +1,111 @@
+package ecdaa
+
+import (
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestEpochRotation(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	now := time.Now()
+
+	epochIssuer := NewEpochIssuer()
+
+	first, err := epochIssuer.Rotate(now.Add(-time.Hour), now.Add(time.Hour), rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	firstIssuer, err := epochIssuer.CurrentIssuer()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	firstSW, err := ExampleJoin(firstIssuer, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	second, err := epochIssuer.Rotate(now, now.Add(2*time.Hour), rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	secondIssuer, err := epochIssuer.Issuer(second.ID)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	secondSW, err := ExampleJoin(secondIssuer, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	encoded, err := epochIssuer.Set.Encode()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var set IPKSet
+	err = set.Decode(encoded)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	firstSigner := NewEpochSigner(firstSW, first.ID)
+	secondSigner := NewEpochSigner(secondSW, second.ID)
+
+	firstSignature, err := firstSigner.Sign(message, basename, rng)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	secondSignature, err := secondSigner.Sign(message, basename, rng)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	t.Run("verify_both_epochs", func(t *testing.T) {
+		err := VerifyWithIPKSet(message, basename, firstSignature, &set, now, RevocationList{})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+
+		err = VerifyWithIPKSet(message, basename, secondSignature, &set, now, RevocationList{})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	})
+
+	t.Run("verify_expired_epoch", func(t *testing.T) {
+		err := VerifyWithIPKSet(message, basename, firstSignature, &set, now.Add(90*time.Minute), RevocationList{})
+		if err == nil {
+			t.Fatalf("verify: epoch is expired but verify say valid")
+		}
+	})
+
+	t.Run("verify_wrong_epoch", func(t *testing.T) {
+		encoded, err := firstSignature.Encode()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var modified Signature
+		err = modified.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		modified.Epoch = second.ID
+
+		err = VerifyWithIPKSet(message, basename, &modified, &set, now, RevocationList{})
+		if err == nil {
+			t.Fatalf("verify: epoch is wrong but verify say valid")
+		}
+	})
+}
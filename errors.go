@@ -0,0 +1,159 @@
+package ecdaa
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+)
+
+/**
+ * Reasons of the verification failures, to be tested with errors.Is.
+ */
+var (
+	ErrMalformed         = errors.New("malformed input")
+	ErrIPKInvalid        = errors.New("IPK is not valid")
+	ErrIPKUntrusted      = errors.New("IPK is not trusted")
+	ErrCredentialInvalid = errors.New("credential is not valid")
+	ErrProofInvalid      = errors.New("proof is not valid")
+	ErrBasenameMismatch  = errors.New("basename is not match")
+	ErrRevoked           = errors.New("revoked")
+
+	// revoked by the secret key or the pseudonym, both are ErrRevoked
+	ErrKeyRevoked       = fmt.Errorf("the secret key %w", ErrRevoked)
+	ErrPseudonymRevoked = fmt.Errorf("the pseudonym %w", ErrRevoked)
+)
+
+/**
+ * Structured failure of the verification, taken with errors.As.
+ *
+ * Reason is one of the sentinel errors above, and Err is the detail.
+ * RevokedEntry is the index of the entry of the revocation list which
+ * revoked the signature (keys for ErrKeyRevoked, signature revocations
+ * for ErrPseudonymRevoked), or -1.
+ */
+type VerifyResult struct {
+	Reason       error
+	RevokedEntry int
+	Err          error
+}
+
+func (result *VerifyResult) Error() string {
+	if result.Err == nil {
+		return result.Reason.Error()
+	}
+
+	return fmt.Sprintf("%v: %v", result.Reason, result.Err)
+}
+
+func (result *VerifyResult) Unwrap() []error {
+	if result.Err == nil {
+		return []error{result.Reason}
+	}
+
+	return []error{result.Reason, result.Err}
+}
+
+func verifyError(reason error, format string, args ...any) error {
+	return &VerifyResult{
+		Reason:       reason,
+		RevokedEntry: -1,
+		Err:          fmt.Errorf(format, args...),
+	}
+}
+
+func revokedError(reason error, entry int) error {
+	return &VerifyResult{
+		Reason:       reason,
+		RevokedEntry: entry,
+	}
+}
+
+func validPoint(P *FP256BN.ECP) bool {
+	return P != nil && !P.Is_infinity()
+}
+
+/**
+ * Check the signature has all the fields, before the verification.
+ */
+func checkSignature(signature *Signature) error {
+	if signature == nil || signature.Proof == nil || signature.RandomizedCred == nil {
+		return verifyError(ErrMalformed, "signature is incomplete")
+	}
+
+	proof := signature.Proof
+	if proof.SmallC == nil || proof.SmallS == nil || proof.SmallN == nil {
+		return verifyError(ErrMalformed, "proof is incomplete")
+	}
+
+	return checkCredential(signature.RandomizedCred)
+}
+
+/**
+ * Check the attribute signature has all the fields, before the verification.
+ */
+func checkAttributeSignature(signature *AttributeSignature) error {
+	if signature == nil {
+		return verifyError(ErrMalformed, "signature is missing")
+	}
+
+	for name, P := range map[string]*FP256BN.ECP{
+		"APrime": signature.APrime,
+		"ABar":   signature.ABar,
+		"D":      signature.D,
+		"Base":   signature.Base,
+		"K":      signature.K,
+	} {
+		if !validPoint(P) {
+			return verifyError(ErrMalformed, "signature %v is missing or infinity", name)
+		}
+	}
+
+	for _, x := range []*FP256BN.BIG{signature.SmallC, signature.SmallN, signature.ZE, signature.ZR2, signature.ZR3, signature.ZS, signature.ZSK} {
+		if x == nil {
+			return verifyError(ErrMalformed, "proof is incomplete")
+		}
+	}
+
+	for _, attributes := range []map[int]*FP256BN.BIG{signature.Disclosed, signature.ZAttributes} {
+		for i, x := range attributes {
+			if x == nil {
+				return verifyError(ErrMalformed, "attribute %v is missing", i)
+			}
+		}
+	}
+
+	for i, proof := range signature.Predicates {
+		if proof == nil {
+			return verifyError(ErrMalformed, "predicate proof %v is missing", i)
+		}
+	}
+
+	return nil
+}
+
+func checkCredential(cred *Credential) error {
+	if cred == nil {
+		return verifyError(ErrMalformed, "credential is missing")
+	}
+
+	for name, P := range map[string]*FP256BN.ECP{"A": cred.A, "B": cred.B, "C": cred.C, "D": cred.D} {
+		if !validPoint(P) {
+			return verifyError(ErrMalformed, "credential %v is missing or infinity", name)
+		}
+	}
+
+	return nil
+}
+
+func checkIPK(ipk *IPK) error {
+	if ipk == nil || ipk.X == nil || ipk.Y == nil || ipk.C == nil || ipk.SX == nil || ipk.SY == nil {
+		return verifyError(ErrMalformed, "IPK is incomplete")
+	}
+
+	if ipk.X.Is_infinity() || ipk.Y.Is_infinity() {
+		return verifyError(ErrMalformed, "IPK is infinity")
+	}
+
+	return nil
+}
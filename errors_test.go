@@ -0,0 +1,137 @@
+package ecdaa
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func expectReason(t *testing.T, err, reason error, entry int) {
+	if !errors.Is(err, reason) {
+		t.Fatalf("error is not %v: %v", reason, err)
+	}
+
+	var result *VerifyResult
+	if !errors.As(err, &result) {
+		t.Fatalf("error is not VerifyResult: %v", err)
+	}
+
+	if result.RevokedEntry != entry {
+		t.Fatalf("revoked entry is %v, not %v", result.RevokedEntry, entry)
+	}
+}
+
+func TestVerifyResult(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	issuer, signer, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	other := RandomIssuer(rng)
+
+	signature, err := signer.Sign(message, basename, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	t.Run("proof", func(t *testing.T) {
+		err := Verify([]byte("piyo"), basename, signature, &issuer.Ipk, RevocationList{})
+		expectReason(t, err, ErrProofInvalid, -1)
+	})
+
+	t.Run("credential", func(t *testing.T) {
+		err := Verify(message, basename, signature, &other.Ipk, RevocationList{})
+		expectReason(t, err, ErrCredentialInvalid, -1)
+	})
+
+	t.Run("IPK", func(t *testing.T) {
+		ipk := issuer.Ipk
+		ipk.C = FP256BN.Modadd(ipk.C, FP256BN.NewBIGint(1), amcl_utils.P())
+
+		expectReason(t, VerifyIPK(&ipk), ErrIPKInvalid, -1)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		malformed := *signature
+		malformed.Proof = nil
+
+		err := Verify(message, basename, &malformed, &issuer.Ipk, RevocationList{})
+		expectReason(t, err, ErrMalformed, -1)
+
+		cred := *signature.RandomizedCred
+		cred.D = FP256BN.NewECP()
+		malformed = *signature
+		malformed.RandomizedCred = &cred
+
+		err = Verify(message, basename, &malformed, &issuer.Ipk, RevocationList{})
+		expectReason(t, err, ErrMalformed, -1)
+	})
+
+	t.Run("basename", func(t *testing.T) {
+		unlinkable, err := signer.Sign(message, nil, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		proof := *unlinkable.Proof
+		proof.K = nil
+		unlinkable.Proof = &proof
+
+		err = Verify(message, basename, unlinkable, &issuer.Ipk, RevocationList{})
+		expectReason(t, err, ErrMalformed, -1)
+	})
+
+	rl := RevocationList{}
+	for i := 0; i < 2*parallelRevocationThreshold; i++ {
+		rl = append(rl, amcl_utils.RandomBig(rng))
+	}
+
+	for _, entry := range []int{1, parallelRevocationThreshold + 3} {
+		revoked := append(RevocationList{}, rl...)
+		revoked[entry] = signer.sk
+		revoked[len(revoked)-1] = signer.sk
+
+		t.Run("revoked_key", func(t *testing.T) {
+			err := Verify(message, basename, signature, &issuer.Ipk, revoked[:parallelRevocationThreshold/2])
+			if entry < parallelRevocationThreshold/2 {
+				expectReason(t, err, ErrKeyRevoked, entry)
+			} else if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			err = Verify(message, basename, signature, &issuer.Ipk, revoked)
+			expectReason(t, err, ErrKeyRevoked, entry)
+
+			if !errors.Is(err, ErrRevoked) {
+				t.Fatalf("error is not ErrRevoked: %v", err)
+			}
+
+			err = NewRevocationChecker(revoked, 4).Check(basename, signature)
+			expectReason(t, err, ErrKeyRevoked, entry)
+		})
+	}
+
+	t.Run("revoked_pseudonym", func(t *testing.T) {
+		revocation, err := NewSignatureRevocation(basename, signature)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		list := SignedRevocationList{
+			Signatures: []SignatureRevocation{*revocation, *revocation},
+		}
+
+		err = list.Check(basename, signature)
+		expectReason(t, err, ErrPseudonymRevoked, 0)
+
+		if !errors.Is(err, ErrRevoked) || errors.Is(err, ErrKeyRevoked) {
+			t.Fatalf("error is not only the pseudonym revoked: %v", err)
+		}
+	})
+}
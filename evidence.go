@@ -0,0 +1,115 @@
+package ecdaa
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+)
+
+/**
+ * Issuer-side hook evaluating the evidence of the device in the join request
+ * (EK certificate, its chain, platform certificates) before issuing.
+ */
+type EvidenceVerifier interface {
+	VerifyEvidence(req *JoinRequestTPM) error
+}
+
+type EvidenceVerifierFunc func(req *JoinRequestTPM) error
+
+func (f EvidenceVerifierFunc) VerifyEvidence(req *JoinRequestTPM) error {
+	return f(req)
+}
+
+/**
+ * Verify the EK certificate chains to the roots (TPM manufacturer CAs),
+ * using the intermediates in the bundle.
+ */
+type EKCertVerifier struct {
+	Roots *x509.CertPool
+	// time to verify at, now if zero
+	CurrentTime time.Time
+}
+
+func (verifier *EKCertVerifier) VerifyEvidence(req *JoinRequestTPM) error {
+	if req.EKCert == nil {
+		return fmt.Errorf("EK cert is not found")
+	}
+
+	intermediates := x509.NewCertPool()
+
+	for _, cert := range req.Bundle.Intermediates {
+		intermediates.AddCert(cert)
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         verifier.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   verifier.CurrentTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	_, err := req.EKCert.Verify(opts)
+	if err != nil {
+		return fmt.Errorf("verify EK cert: %v", err)
+	}
+
+	return nil
+}
+
+/**
+ * Evaluate the platform certificates with Verify, which is given each
+ * certificate in DER and the EK certificate it should be bound to.
+ * If Required is set, a request without platform certificates is rejected.
+ */
+type PlatformCertVerifier struct {
+	Verify   func(der []byte, ekCert *x509.Certificate) error
+	Required bool
+}
+
+func (verifier *PlatformCertVerifier) VerifyEvidence(req *JoinRequestTPM) error {
+	if len(req.Bundle.PlatformCerts) == 0 {
+		if verifier.Required {
+			return fmt.Errorf("platform cert is not found")
+		}
+
+		return nil
+	}
+
+	for i, der := range req.Bundle.PlatformCerts {
+		err := verifier.Verify(der, req.EKCert)
+		if err != nil {
+			return fmt.Errorf("verify platform cert %v: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Run all the verifiers on the join request.
+ */
+func VerifyJoinEvidence(req *JoinRequestTPM, verifiers ...EvidenceVerifier) error {
+	for _, verifier := range verifiers {
+		err := verifier.VerifyEvidence(req)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Step3. make the encrypted credential if the request passes the verifiers (by Issuer)
+ */
+func (issuer *Issuer) MakeCredEncryptedWithEvidence(req *JoinRequestTPM, B *FP256BN.ECP, verifiers []EvidenceVerifier, rng *core.RAND) (*CredentialCipher, *Credential, error) {
+	err := VerifyJoinEvidence(req, verifiers...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("evidence: %v", err)
+	}
+
+	return issuer.MakeCredEncrypted(req, B, rng)
+}
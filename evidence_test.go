@@ -0,0 +1,158 @@
+package ecdaa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func testCert(t *testing.T, name string, serial int64, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageKeyEncipherment,
+	}
+
+	if parent == nil {
+		parent, parentKey = &template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return cert, key
+}
+
+func testJoinRequestTPM(t *testing.T) (*JoinRequestTPM, *x509.CertPool) {
+	rng := amcl_utils.InitRandom()
+
+	root, rootKey := testCert(t, "root", 1, true, nil, nil)
+	intermediate, intermediateKey := testCert(t, "intermediate", 2, true, root, rootKey)
+	ek, _ := testCert(t, "ek", 3, false, intermediate, intermediateKey)
+
+	seed, _, err := GenJoinSeed(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	req, _, err := GenJoinReq(seed, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	reqTPM := JoinRequestTPM{
+		JoinReq: req,
+		EKCert:  ek,
+		SrkName: []byte("srk"),
+		Bundle: CertBundle{
+			Intermediates: []*x509.Certificate{intermediate},
+			PlatformCerts: [][]byte{{0x30, 0x00}},
+		},
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	return &reqTPM, roots
+}
+
+func TestEncodeDecodeCertBundle(t *testing.T) {
+	req, _ := testJoinRequestTPM(t)
+
+	encoded, err := req.Encode()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var decoded JoinRequestTPM
+
+	err = decoded.Decode(encoded)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if len(decoded.Bundle.Intermediates) != 1 || !decoded.Bundle.Intermediates[0].Equal(req.Bundle.Intermediates[0]) {
+		t.Fatalf("intermediates are not match")
+	}
+
+	if !reflect.DeepEqual(decoded.Bundle.PlatformCerts, req.Bundle.PlatformCerts) {
+		t.Fatalf("platform certs are not match")
+	}
+}
+
+func TestVerifyJoinEvidence(t *testing.T) {
+	req, roots := testJoinRequestTPM(t)
+
+	ekVerifier := EKCertVerifier{Roots: roots}
+	platformVerifier := PlatformCertVerifier{
+		Verify: func(der []byte, ekCert *x509.Certificate) error {
+			if ekCert != req.EKCert {
+				return fmt.Errorf("wrong EK cert")
+			}
+
+			return nil
+		},
+		Required: true,
+	}
+
+	err := VerifyJoinEvidence(req, &ekVerifier, &platformVerifier)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	t.Run("no intermediates", func(t *testing.T) {
+		broken := *req
+		broken.Bundle = CertBundle{}
+
+		err := VerifyJoinEvidence(&broken, &ekVerifier)
+		if err == nil {
+			t.Fatalf("verified without the chain")
+		}
+	})
+
+	t.Run("no platform certs", func(t *testing.T) {
+		broken := *req
+		broken.Bundle.PlatformCerts = nil
+
+		err := VerifyJoinEvidence(&broken, &platformVerifier)
+		if err == nil {
+			t.Fatalf("verified without platform certs")
+		}
+	})
+
+	t.Run("func", func(t *testing.T) {
+		reject := EvidenceVerifierFunc(func(req *JoinRequestTPM) error {
+			return fmt.Errorf("rejected")
+		})
+
+		err := VerifyJoinEvidence(req, &ekVerifier, reject)
+		if err == nil {
+			t.Fatalf("verified with rejecting verifier")
+		}
+	})
+}
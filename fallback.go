@@ -0,0 +1,218 @@
+package ecdaa
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+/**
+ * Where the member key lives after joining.
+ */
+type JoinMode int
+
+const (
+	// ECDAA key generated in the TPM (TPMSigner)
+	JoinModeTPM JoinMode = iota
+	// software key sealed to the TPM (SealedSigner)
+	JoinModeSealed
+	// software key in memory (SWSigner)
+	JoinModeSoftware
+)
+
+func (mode JoinMode) String() string {
+	switch mode {
+	case JoinModeTPM:
+		return "tpm"
+	case JoinModeSealed:
+		return "sealed"
+	case JoinModeSoftware:
+		return "software"
+	}
+
+	return fmt.Sprintf("JoinMode(%d)", int(mode))
+}
+
+/**
+ * Policy of falling back when the TPM lacks the requirements.
+ * The modes are tried in order, and the first one the TPM supports
+ * and joins with successfully is used.
+ */
+type FallbackPolicy struct {
+	Modes []JoinMode
+	// PCRs the sealed key is bound to
+	PCRs []uint
+}
+
+/**
+ * Use the TPM key, or seal the key to the TPM; never keep it in plain memory.
+ */
+var DefaultFallbackPolicy = FallbackPolicy{
+	Modes: []JoinMode{JoinModeTPM, JoinModeSealed},
+}
+
+/**
+ * Error which reports why every mode of the policy was rejected.
+ */
+type FallbackError struct {
+	Reasons map[JoinMode]error
+	Modes   []JoinMode
+}
+
+func (err *FallbackError) Error() string {
+	reasons := []string{}
+
+	for _, mode := range err.Modes {
+		reasons = append(reasons, fmt.Sprintf("%v: %v", mode, err.Reasons[mode]))
+	}
+
+	return fmt.Sprintf("no join mode is available (%v)", strings.Join(reasons, "; "))
+}
+
+/**
+ * Pick the first mode of the policy which the capabilities satisfy.
+ * caps may be nil if there is no TPM.
+ */
+func SelectJoinMode(caps *tpm_utils.Capabilities, policy *FallbackPolicy) (JoinMode, error) {
+	fallbackErr := FallbackError{
+		Reasons: map[JoinMode]error{},
+		Modes:   policy.Modes,
+	}
+
+	for _, mode := range policy.Modes {
+		err := checkJoinMode(caps, mode)
+		if err == nil {
+			return mode, nil
+		}
+
+		fallbackErr.Reasons[mode] = err
+	}
+
+	return 0, &fallbackErr
+}
+
+func checkJoinMode(caps *tpm_utils.Capabilities, mode JoinMode) error {
+	switch mode {
+	case JoinModeTPM:
+		return checkCapabilities(caps, tpm_utils.ECDAARequirements)
+	case JoinModeSealed:
+		return checkCapabilities(caps, tpm_utils.SealRequirements)
+	case JoinModeSoftware:
+		return nil
+	}
+
+	return fmt.Errorf("unknown join mode")
+}
+
+func checkCapabilities(caps *tpm_utils.Capabilities, reqs []tpm_utils.Requirement) error {
+	if caps == nil {
+		return fmt.Errorf("TPM is not available")
+	}
+
+	return caps.Check(reqs)
+}
+
+/**
+ * Result of joining with the fallback policy.
+ * The fields except Mode and Request are set according to the mode.
+ */
+type JoinResult struct {
+	Mode JoinMode
+	// request to be verified by VerifyJoinReq (for all modes)
+	Request *JoinRequest
+	// request to be passed to MakeCredEncrypted (JoinModeTPM)
+	RequestTPM *JoinRequestTPM
+	Handles    *KeyHandles
+	// sealed secret key (JoinModeSealed)
+	Blob *tpm_utils.SealedBlob
+	// secret key (JoinModeSoftware)
+	SK *FP256BN.BIG
+}
+
+/**
+ * Step2. generate request for join in the first mode of the policy
+ * which the TPM supports (by Member). If the join fails in the mode
+ * (e.g. the TPM refuses the commands), the next mode is tried.
+ * tpm may be nil if there is no TPM.
+ */
+func GenJoinReqWithPolicy(seed *JoinSeed, tpm *tpm_utils.TPM, policy *FallbackPolicy, rng *core.RAND) (*JoinResult, error) {
+	var caps *tpm_utils.Capabilities
+
+	if tpm != nil {
+		var err error
+		caps, err = tpm.Capabilities()
+
+		if err != nil {
+			return nil, fmt.Errorf("probe capabilities: %v", err)
+		}
+	}
+
+	fallbackErr := FallbackError{
+		Reasons: map[JoinMode]error{},
+		Modes:   policy.Modes,
+	}
+
+	for _, mode := range policy.Modes {
+		err := checkJoinMode(caps, mode)
+
+		if err == nil {
+			var result *JoinResult
+
+			result, err = genJoinReqWithMode(seed, tpm, mode, policy, rng)
+			if err == nil {
+				return result, nil
+			}
+		}
+
+		fallbackErr.Reasons[mode] = err
+	}
+
+	return nil, &fallbackErr
+}
+
+func genJoinReqWithMode(seed *JoinSeed, tpm *tpm_utils.TPM, mode JoinMode, policy *FallbackPolicy, rng *core.RAND) (*JoinResult, error) {
+	var err error
+
+	result := JoinResult{Mode: mode}
+
+	switch mode {
+	case JoinModeTPM:
+		result.RequestTPM, result.Handles, err = GenJoinReqWithTPM(seed, tpm, rng)
+
+		if err == nil {
+			result.Request = result.RequestTPM.JoinReq
+		}
+	case JoinModeSealed:
+		result.Request, result.Blob, err = GenJoinReqSealed(seed, tpm, policy.PCRs, rng)
+	case JoinModeSoftware:
+		result.Request, result.SK, err = GenJoinReq(seed, rng)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+/**
+ * Signer of the mode with the issued credential.
+ * For JoinModeTPM, the credential is the one activated by ActivateCredential.
+ */
+func (result *JoinResult) Signer(cred *Credential, tpm *tpm_utils.TPM) (Signer, error) {
+	switch result.Mode {
+	case JoinModeTPM:
+		signer := NewTPMSigner(cred, result.Handles, tpm)
+		return &signer, nil
+	case JoinModeSealed:
+		return NewSealedSigner(cred, result.Blob, tpm), nil
+	case JoinModeSoftware:
+		return NewSWSigner(cred, result.SK), nil
+	}
+
+	return nil, fmt.Errorf("unknown join mode: %v", result.Mode)
+}
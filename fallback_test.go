@@ -0,0 +1,111 @@
+package ecdaa
+
+import (
+	"errors"
+	"testing"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+	"github.com/google/go-tpm/tpm2"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+func TestSelectJoinMode(t *testing.T) {
+	full := tpm_utils.Capabilities{
+		Curves:     []tpm2.TPMECCCurve{tpm2.TPMECCNistP256, tpm2.TPMECCBNP256},
+		Algorithms: []tpm2.TPMAlgID{tpm2.TPMAlgECDAA, tpm2.TPMAlgKeyedHash},
+		Commands: []tpm2.TPMCC{
+			tpm2.TPMCCCommit, tpm2.TPMCCSign, tpm2.TPMCCActivateCredential,
+			tpm2.TPMCCNVRead, tpm2.TPMCCCreate, tpm2.TPMCCUnseal,
+		},
+		NVIndices: []tpm2.TPMHandle{tpm_utils.EK_CERT_INDEX},
+	}
+
+	noECDAA := full
+	noECDAA.Curves = []tpm2.TPMECCCurve{tpm2.TPMECCNistP256}
+	noECDAA.Algorithms = []tpm2.TPMAlgID{tpm2.TPMAlgKeyedHash}
+
+	t.Run("tpm", func(t *testing.T) {
+		mode, err := SelectJoinMode(&full, &DefaultFallbackPolicy)
+		if err != nil || mode != JoinModeTPM {
+			t.Fatalf("want tpm got %v: %v", mode, err)
+		}
+	})
+
+	t.Run("sealed", func(t *testing.T) {
+		mode, err := SelectJoinMode(&noECDAA, &DefaultFallbackPolicy)
+		if err != nil || mode != JoinModeSealed {
+			t.Fatalf("want sealed got %v: %v", mode, err)
+		}
+	})
+
+	t.Run("no tpm", func(t *testing.T) {
+		policy := FallbackPolicy{Modes: []JoinMode{JoinModeTPM, JoinModeSealed, JoinModeSoftware}}
+
+		mode, err := SelectJoinMode(nil, &policy)
+		if err != nil || mode != JoinModeSoftware {
+			t.Fatalf("want software got %v: %v", mode, err)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		policy := FallbackPolicy{Modes: []JoinMode{JoinModeTPM}}
+
+		_, err := SelectJoinMode(&noECDAA, &policy)
+
+		var fallbackErr *FallbackError
+		if !errors.As(err, &fallbackErr) {
+			t.Fatalf("want FallbackError got %v", err)
+		}
+
+		var unsupported *tpm_utils.UnsupportedError
+		if !errors.As(fallbackErr.Reasons[JoinModeTPM], &unsupported) {
+			t.Fatalf("want UnsupportedError got %v", fallbackErr.Reasons[JoinModeTPM])
+		}
+
+		if len(unsupported.Missing) != 2 {
+			t.Fatalf("want 2 missing got %v", unsupported.Missing)
+		}
+	})
+}
+
+func TestJoinWithPolicySoftware(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	policy := FallbackPolicy{Modes: []JoinMode{JoinModeTPM, JoinModeSoftware}}
+
+	issuer, err := testIssuer(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	seed, issuerB, err := GenJoinSeed(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	result, err := GenJoinReqWithPolicy(seed, nil, &policy, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if result.Mode != JoinModeSoftware {
+		t.Fatalf("want software got %v", result.Mode)
+	}
+
+	err = VerifyJoinReq(result.Request, seed, issuerB)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	cred, err := issuer.MakeCred(result.Request, issuerB, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	signer, err := result.Signer(cred, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	testSignAndVerify(t, signer, issuer)
+}
@@ -5,9 +5,15 @@ go 1.21.4
 require (
 	github.com/akakou-fork/amcl-go/miracl v0.0.0-20240206094909-344c847a50cc
 	github.com/akakou/fp256bn-amcl-utils v0.0.2
+	github.com/fxamacker/cbor/v2 v2.6.0
 	github.com/google/go-tpm v0.9.1-0.20240206213016-638c2b803c16
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/crypto v0.18.0
 )
 
-require golang.org/x/sys v0.16.0 // indirect
+require (
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+)
 
 // replace github.com/google/go-tpm => ../tmp/go-tpm
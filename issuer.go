@@ -1,8 +1,6 @@
 package ecdaa
 
 import (
-	"fmt"
-
 	"github.com/akakou-fork/amcl-go/miracl/core"
 	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
 	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
@@ -101,6 +99,11 @@ func RandomIPK(isk *ISK, rng *core.RAND) IPK {
  * Check IPK is valid.
  */
 func VerifyIPK(ipk *IPK) error {
+	err := checkIPK(ipk)
+	if err != nil {
+		return err
+	}
+
 	X := ipk.X
 	Y := ipk.Y
 	c := ipk.C
@@ -125,7 +128,7 @@ func VerifyIPK(ipk *IPK) error {
 	if FP256BN.Comp(c, cDash) == 0 {
 		return nil
 	} else {
-		return fmt.Errorf("IPK is not valid\n")
+		return verifyError(ErrIPKInvalid, "c is not match")
 	}
 }
 
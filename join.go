@@ -3,6 +3,7 @@ package ecdaa
 import (
 	"crypto/x509"
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"github.com/akakou-fork/amcl-go/miracl/core"
@@ -49,10 +50,43 @@ type JoinRequest struct {
 	Q     *FP256BN.ECP
 }
 
+/**
+ * Certificates which come with the EK certificate as the evidence of the device.
+ */
+type CertBundle struct {
+	// intermediate CA certificates of the EK certificate
+	Intermediates []*x509.Certificate
+	// TCG platform certificates in DER (X.509 attribute certificates)
+	PlatformCerts [][]byte
+}
+
 type JoinRequestTPM struct {
 	JoinReq *JoinRequest
 	EKCert  *x509.Certificate
 	SrkName []byte
+	Bundle  CertBundle
+	// attested platform state (GenJoinReqWithQuote only)
+	Quote *JoinQuote
+}
+
+/**
+ * The point B of the seed, derived from (S2, Y2) as the member does.
+ */
+func seedPoint(seed *JoinSeed) (*FP256BN.ECP, error) {
+	if seed == nil || seed.Y2 == nil {
+		return nil, fmt.Errorf("join seed is incomplete")
+	}
+
+	hash := amcl_utils.NewHash()
+	hash.WriteBytes(seed.S2)
+	bX := hash.SumToBIG()
+
+	B := FP256BN.NewECPbigs(bX, seed.Y2)
+	if B.Is_infinity() {
+		return nil, fmt.Errorf("join seed is not on the curve")
+	}
+
+	return B, nil
 }
 
 /**
@@ -62,6 +96,15 @@ func GenJoinReq(seed *JoinSeed, rng *core.RAND) (*JoinRequest, *FP256BN.BIG, err
 	/* create key and get public key */
 	sk := amcl_utils.RandomBig(rng)
 
+	req := GenJoinReqWithKey(seed, sk, rng)
+
+	return req, sk, nil
+}
+
+/**
+ * Step2. generate request for join with the existing key (by Member)
+ */
+func GenJoinReqWithKey(seed *JoinSeed, sk *FP256BN.BIG, rng *core.RAND) *JoinRequest {
 	/* set zero buffers to P1 */
 	hash := amcl_utils.NewHash()
 	hash.WriteBytes(seed.S2)
@@ -78,7 +121,28 @@ func GenJoinReq(seed *JoinSeed, rng *core.RAND) (*JoinRequest, *FP256BN.BIG, err
 		Q,
 	}
 
-	return &req, sk, nil
+	return &req
+}
+
+/**
+ * Flush the transient objects of the handles from the TPM.
+ */
+func (handles *KeyHandles) Flush(tpm *tpm_utils.TPM) {
+	if handles.Handle != nil {
+		tpm.Flush(handles.Handle.Handle)
+	}
+
+	if handles.EkHandle != nil {
+		tpm.Flush(handles.EkHandle.Handle)
+	}
+
+	if handles.SrkHandle != nil {
+		tpm.Flush(handles.SrkHandle.Handle)
+	}
+
+	if handles.AkHandle != nil {
+		tpm.Flush(handles.AkHandle.Handle)
+	}
 }
 
 func GenJoinReqWithTPM(seed *JoinSeed, tpm *tpm_utils.TPM, rng *core.RAND) (*JoinRequestTPM, *KeyHandles, error) {
@@ -89,6 +153,28 @@ func GenJoinReqWithTPM(seed *JoinSeed, tpm *tpm_utils.TPM, rng *core.RAND) (*Joi
 		return nil, nil, err
 	}
 
+	keyHandles := KeyHandles{
+		EkHandle:  ekHandle,
+		SrkHandle: srkHandle,
+		Handle:    handle,
+	}
+
+	reqTPM, err := GenJoinReqWithTPMKey(seed, tpm, &keyHandles, rng)
+
+	if err != nil {
+		keyHandles.Flush(tpm)
+		return nil, nil, err
+	}
+
+	return reqTPM, &keyHandles, nil
+}
+
+/**
+ * Step2. generate request for join with the existing key in TPM (by Member)
+ */
+func GenJoinReqWithTPMKey(seed *JoinSeed, tpm *tpm_utils.TPM, handles *KeyHandles, rng *core.RAND) (*JoinRequestTPM, error) {
+	handle := handles.Handle
+
 	hash := amcl_utils.NewHash()
 	hash.WriteBytes(seed.S2)
 	bX := hash.SumToBIG()
@@ -99,7 +185,7 @@ func GenJoinReqWithTPM(seed *JoinSeed, tpm *tpm_utils.TPM, rng *core.RAND) (*Joi
 	comRsp, E, _, K, err := (*tpm).Commit(handle, B, seed.S2, B)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("commit error: %v", err)
+		return nil, fmt.Errorf("commit error: %v", err)
 	}
 
 	/* calc hash c2 = H( U1 | P1 | Q | m ) */
@@ -113,7 +199,7 @@ func GenJoinReqWithTPM(seed *JoinSeed, tpm *tpm_utils.TPM, rng *core.RAND) (*Joi
 	_, s1, n, err := (*tpm).Sign(c2Buf[:], comRsp.Counter, handle)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("sign error: %v", err)
+		return nil, fmt.Errorf("sign error: %v", err)
 	}
 
 	/* calc hash c1 = H( n | c2 ) */
@@ -125,9 +211,12 @@ func GenJoinReqWithTPM(seed *JoinSeed, tpm *tpm_utils.TPM, rng *core.RAND) (*Joi
 	EKCert, err := (*tpm).ReadEKCert()
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("sign error: %v", err)
+		return nil, fmt.Errorf("sign error: %v", err)
 	}
 
+	// best-effort: the verifier of the request decides if the rest is enough
+	bundle, _ := ReadCertBundle(tpm)
+
 	proof := SchnorrProof{
 		SmallC: c1,
 		SmallN: n,
@@ -142,16 +231,37 @@ func GenJoinReqWithTPM(seed *JoinSeed, tpm *tpm_utils.TPM, rng *core.RAND) (*Joi
 	reqTPM := JoinRequestTPM{
 		EKCert:  EKCert,
 		JoinReq: &req,
-		SrkName: srkHandle.Name.Buffer,
+		SrkName: handles.SrkHandle.Name.Buffer,
+		Bundle:  *bundle,
 	}
 
-	keyHandles := KeyHandles{
-		EkHandle:  ekHandle,
-		SrkHandle: srkHandle,
-		Handle:    handle,
+	return &reqTPM, nil
+}
+
+/**
+ * Read the certificates stored in the TPM other than the EK certificate.
+ *
+ * The bundle is always returned with the certificates which could be read,
+ * and the error tells the ones which could not (e.g. missing or
+ * auth-protected NV indices, or malformed DER).
+ */
+func ReadCertBundle(tpm *tpm_utils.TPM) (*CertBundle, error) {
+	var bundle CertBundle
+	var chainErr, platformErr error
+
+	bundle.Intermediates, chainErr = tpm.ReadEKCertChain()
+
+	if chainErr != nil {
+		chainErr = fmt.Errorf("read EK cert chain: %v", chainErr)
+	}
+
+	bundle.PlatformCerts, platformErr = tpm.ReadPlatformCerts()
+
+	if platformErr != nil {
+		platformErr = fmt.Errorf("read platform certs: %v", platformErr)
 	}
 
-	return &reqTPM, &keyHandles, nil
+	return &bundle, errors.Join(chainErr, platformErr)
 }
 
 func VerifyJoinReq(req *JoinRequest, seed *JoinSeed, B *FP256BN.ECP) error {
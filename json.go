@@ -0,0 +1,684 @@
+package ecdaa
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+/**
+ * JSON representation of the protocol objects.
+ *
+ * Every object carries its type and version, and the byte strings
+ * (the same encodings of the points and the numbers as the gob forms)
+ * are in unpadded base64url as in JWK. The keys carry the curve name.
+ */
+
+const (
+	JSONVersion = 1
+	JSONCurve   = "FP256BN"
+
+	JSONTypeISK              = "ecdaa-isk"
+	JSONTypeIPK              = "ecdaa-ipk"
+	JSONTypeJoinSeed         = "ecdaa-join-seed"
+	JSONTypeJoinRequest      = "ecdaa-join-request"
+	JSONTypeJoinRequestTPM   = "ecdaa-join-request-tpm"
+	JSONTypeCredentialCipher = "ecdaa-credential-cipher"
+	JSONTypeCredential       = "ecdaa-credential"
+	JSONTypeSchnorrProof     = "ecdaa-schnorr-proof"
+	JSONTypeSignature        = "ecdaa-signature"
+	JSONTypeRevocationList   = "ecdaa-revocation-list"
+)
+
+/**
+ * Byte string in unpadded base64url.
+ */
+type Base64URL []byte
+
+func (buf Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(buf))
+}
+
+func (decoded *Base64URL) UnmarshalJSON(encoded []byte) error {
+	var str string
+
+	err := json.Unmarshal(encoded, &str)
+	if err != nil {
+		return err
+	}
+
+	buf, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return err
+	}
+
+	*decoded = buf
+
+	return nil
+}
+
+type MiddleJSONHeader struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+}
+
+func jsonHeader(typ string) MiddleJSONHeader {
+	return MiddleJSONHeader{
+		Type:    typ,
+		Version: JSONVersion,
+	}
+}
+
+/**
+ * Decode the object after checking its type and version.
+ */
+func decodeJSON[T any](encoded []byte, typ string, mid *T) error {
+	var header MiddleJSONHeader
+
+	err := json.Unmarshal(encoded, &header)
+	if err != nil {
+		return err
+	}
+
+	if header.Type != typ {
+		return verifyError(ErrMalformed, "type is not match: %v != %v", header.Type, typ)
+	}
+
+	if header.Version != JSONVersion {
+		return verifyError(ErrMalformed, "version %v of %v is not supported", header.Version, typ)
+	}
+
+	return json.Unmarshal(encoded, mid)
+}
+
+func checkJSONCurve(curve string) error {
+	if curve != JSONCurve {
+		return verifyError(ErrMalformed, "curve %v is not supported", curve)
+	}
+
+	return nil
+}
+
+func jsonECP(P *FP256BN.ECP) Base64URL {
+	if P == nil {
+		return nil
+	}
+
+	return amcl_utils.EcpToBytes(P)
+}
+
+const (
+	jsonBIGSize  = int(FP256BN.MODBYTES)
+	jsonECPSize  = jsonBIGSize + 1
+	jsonECP2Size = 2*jsonBIGSize + 1
+)
+
+func jsonToBIG(name string, buf Base64URL) (*FP256BN.BIG, error) {
+	if len(buf) != jsonBIGSize {
+		return nil, verifyError(ErrMalformed, "%v is %v bytes, not %v", name, len(buf), jsonBIGSize)
+	}
+
+	return FP256BN.FromBytes(buf), nil
+}
+
+func jsonToECP(name string, buf Base64URL) (*FP256BN.ECP, error) {
+	if len(buf) != jsonECPSize {
+		return nil, verifyError(ErrMalformed, "%v is %v bytes, not %v", name, len(buf), jsonECPSize)
+	}
+
+	P := FP256BN.ECP_fromBytes(buf)
+	if P.Is_infinity() {
+		return nil, verifyError(ErrMalformed, "%v is not on the curve", name)
+	}
+
+	return P, nil
+}
+
+func jsonToECP2(name string, buf Base64URL) (*FP256BN.ECP2, error) {
+	if len(buf) != jsonECP2Size {
+		return nil, verifyError(ErrMalformed, "%v is %v bytes, not %v", name, len(buf), jsonECP2Size)
+	}
+
+	P := FP256BN.ECP2_fromBytes(buf)
+	if P.Is_infinity() {
+		return nil, verifyError(ErrMalformed, "%v is not on the curve", name)
+	}
+
+	return P, nil
+}
+
+/**
+ * The ISK is tagged as secret, so that tools and logs can refuse
+ * or redact it.
+ */
+type MiddleJSONISK struct {
+	MiddleJSONHeader
+	Curve  string    `json:"crv"`
+	Secret bool      `json:"secret"`
+	X      Base64URL `json:"x"`
+	Y      Base64URL `json:"y"`
+}
+
+func (isk *ISK) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MiddleJSONISK{
+		MiddleJSONHeader: jsonHeader(JSONTypeISK),
+		Curve:            JSONCurve,
+		Secret:           true,
+		X:                amcl_utils.BigToBytes(isk.X),
+		Y:                amcl_utils.BigToBytes(isk.Y),
+	})
+}
+
+func (decoded *ISK) UnmarshalJSON(encoded []byte) error {
+	var mid MiddleJSONISK
+
+	err := decodeJSON(encoded, JSONTypeISK, &mid)
+	if err != nil {
+		return err
+	}
+
+	err = checkJSONCurve(mid.Curve)
+	if err != nil {
+		return err
+	}
+
+	X, err := jsonToBIG("x", mid.X)
+	if err != nil {
+		return err
+	}
+
+	Y, err := jsonToBIG("y", mid.Y)
+	if err != nil {
+		return err
+	}
+
+	decoded.X = X
+	decoded.Y = Y
+
+	return nil
+}
+
+type MiddleJSONIPK struct {
+	MiddleJSONHeader
+	Curve string    `json:"crv"`
+	X     Base64URL `json:"x"`
+	Y     Base64URL `json:"y"`
+	C     Base64URL `json:"c"`
+	SX    Base64URL `json:"sx"`
+	SY    Base64URL `json:"sy"`
+}
+
+func (ipk *IPK) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MiddleJSONIPK{
+		MiddleJSONHeader: jsonHeader(JSONTypeIPK),
+		Curve:            JSONCurve,
+		X:                amcl_utils.Ecp2ToBytes(ipk.X),
+		Y:                amcl_utils.Ecp2ToBytes(ipk.Y),
+		C:                amcl_utils.BigToBytes(ipk.C),
+		SX:               amcl_utils.BigToBytes(ipk.SX),
+		SY:               amcl_utils.BigToBytes(ipk.SY),
+	})
+}
+
+func (decoded *IPK) UnmarshalJSON(encoded []byte) error {
+	var mid MiddleJSONIPK
+
+	err := decodeJSON(encoded, JSONTypeIPK, &mid)
+	if err != nil {
+		return err
+	}
+
+	err = checkJSONCurve(mid.Curve)
+	if err != nil {
+		return err
+	}
+
+	X, err := jsonToECP2("x", mid.X)
+	if err != nil {
+		return err
+	}
+
+	Y, err := jsonToECP2("y", mid.Y)
+	if err != nil {
+		return err
+	}
+
+	scalars := []*FP256BN.BIG{}
+
+	for _, field := range []struct {
+		name string
+		buf  Base64URL
+	}{{"c", mid.C}, {"sx", mid.SX}, {"sy", mid.SY}} {
+		x, err := jsonToBIG(field.name, field.buf)
+		if err != nil {
+			return err
+		}
+
+		scalars = append(scalars, x)
+	}
+
+	decoded.X = X
+	decoded.Y = Y
+	decoded.C = scalars[0]
+	decoded.SX = scalars[1]
+	decoded.SY = scalars[2]
+
+	return nil
+}
+
+type MiddleJSONJoinSeed struct {
+	MiddleJSONHeader
+	Basename Base64URL `json:"basename"`
+	S2       Base64URL `json:"s2"`
+	Y2       Base64URL `json:"y2"`
+}
+
+func (seed *JoinSeed) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MiddleJSONJoinSeed{
+		MiddleJSONHeader: jsonHeader(JSONTypeJoinSeed),
+		Basename:         seed.Basename,
+		S2:               seed.S2,
+		Y2:               amcl_utils.BigToBytes(seed.Y2),
+	})
+}
+
+func (decoded *JoinSeed) UnmarshalJSON(encoded []byte) error {
+	var mid MiddleJSONJoinSeed
+
+	err := decodeJSON(encoded, JSONTypeJoinSeed, &mid)
+	if err != nil {
+		return err
+	}
+
+	Y2, err := jsonToBIG("y2", mid.Y2)
+	if err != nil {
+		return err
+	}
+
+	decoded.Basename = mid.Basename
+	decoded.S2 = mid.S2
+	decoded.Y2 = Y2
+
+	return nil
+}
+
+type MiddleJSONSchnorrProof struct {
+	MiddleJSONHeader
+	SmallC Base64URL `json:"c"`
+	SmallS Base64URL `json:"s"`
+	SmallN Base64URL `json:"n"`
+	K      Base64URL `json:"k,omitempty"`
+}
+
+func (proof *SchnorrProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MiddleJSONSchnorrProof{
+		MiddleJSONHeader: jsonHeader(JSONTypeSchnorrProof),
+		SmallC:           amcl_utils.BigToBytes(proof.SmallC),
+		SmallS:           amcl_utils.BigToBytes(proof.SmallS),
+		SmallN:           amcl_utils.BigToBytes(proof.SmallN),
+		K:                jsonECP(proof.K),
+	})
+}
+
+func (decoded *SchnorrProof) UnmarshalJSON(encoded []byte) error {
+	var mid MiddleJSONSchnorrProof
+
+	err := decodeJSON(encoded, JSONTypeSchnorrProof, &mid)
+	if err != nil {
+		return err
+	}
+
+	c, err := jsonToBIG("c", mid.SmallC)
+	if err != nil {
+		return err
+	}
+
+	s, err := jsonToBIG("s", mid.SmallS)
+	if err != nil {
+		return err
+	}
+
+	n, err := jsonToBIG("n", mid.SmallN)
+	if err != nil {
+		return err
+	}
+
+	var K *FP256BN.ECP
+
+	if mid.K != nil {
+		K, err = jsonToECP("k", mid.K)
+		if err != nil {
+			return err
+		}
+	}
+
+	decoded.SmallC = c
+	decoded.SmallS = s
+	decoded.SmallN = n
+	decoded.K = K
+
+	return nil
+}
+
+type MiddleJSONJoinRequest struct {
+	MiddleJSONHeader
+	Proof *SchnorrProof `json:"proof"`
+	Q     Base64URL     `json:"q"`
+}
+
+func (request *JoinRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MiddleJSONJoinRequest{
+		MiddleJSONHeader: jsonHeader(JSONTypeJoinRequest),
+		Proof:            request.Proof,
+		Q:                amcl_utils.EcpToBytes(request.Q),
+	})
+}
+
+func (decoded *JoinRequest) UnmarshalJSON(encoded []byte) error {
+	var mid MiddleJSONJoinRequest
+
+	err := decodeJSON(encoded, JSONTypeJoinRequest, &mid)
+	if err != nil {
+		return err
+	}
+
+	if mid.Proof == nil {
+		return verifyError(ErrMalformed, "proof is missing")
+	}
+
+	Q, err := jsonToECP("q", mid.Q)
+	if err != nil {
+		return err
+	}
+
+	decoded.Proof = mid.Proof
+	decoded.Q = Q
+
+	return nil
+}
+
+type MiddleJSONJoinQuote struct {
+	AKPublic  Base64URL          `json:"akPublic"`
+	Quoted    Base64URL          `json:"quoted"`
+	Signature Base64URL          `json:"signature"`
+	PCRs      map[uint]Base64URL `json:"pcrs"`
+	EventLog  Base64URL          `json:"eventLog,omitempty"`
+}
+
+type MiddleJSONJoinRequestTPM struct {
+	MiddleJSONHeader
+	JoinReq       *JoinRequest         `json:"joinRequest"`
+	EKCert        Base64URL            `json:"ekCert"`
+	SrkName       Base64URL            `json:"srkName"`
+	Intermediates []Base64URL          `json:"intermediates,omitempty"`
+	PlatformCerts []Base64URL          `json:"platformCerts,omitempty"`
+	Quote         *MiddleJSONJoinQuote `json:"quote,omitempty"`
+}
+
+func (request *JoinRequestTPM) MarshalJSON() ([]byte, error) {
+	mid := MiddleJSONJoinRequestTPM{
+		MiddleJSONHeader: jsonHeader(JSONTypeJoinRequestTPM),
+		JoinReq:          request.JoinReq,
+		EKCert:           request.EKCert.Raw,
+		SrkName:          request.SrkName,
+	}
+
+	for _, cert := range request.Bundle.Intermediates {
+		mid.Intermediates = append(mid.Intermediates, cert.Raw)
+	}
+
+	for _, cert := range request.Bundle.PlatformCerts {
+		mid.PlatformCerts = append(mid.PlatformCerts, cert)
+	}
+
+	if request.Quote != nil {
+		mid.Quote = &MiddleJSONJoinQuote{
+			AKPublic:  request.Quote.AKPublic,
+			Quoted:    request.Quote.Quote.Quoted,
+			Signature: request.Quote.Quote.Signature,
+			PCRs:      map[uint]Base64URL{},
+			EventLog:  request.Quote.EventLog,
+		}
+
+		for index, digest := range request.Quote.Quote.PCRs {
+			mid.Quote.PCRs[index] = digest
+		}
+	}
+
+	return json.Marshal(mid)
+}
+
+func (decoded *JoinRequestTPM) UnmarshalJSON(encoded []byte) error {
+	var mid MiddleJSONJoinRequestTPM
+
+	err := decodeJSON(encoded, JSONTypeJoinRequestTPM, &mid)
+	if err != nil {
+		return err
+	}
+
+	if mid.JoinReq == nil {
+		return verifyError(ErrMalformed, "join request is missing")
+	}
+
+	decoded.JoinReq = mid.JoinReq
+	decoded.SrkName = mid.SrkName
+
+	decoded.EKCert, err = x509.ParseCertificate(mid.EKCert)
+	if err != nil {
+		return err
+	}
+
+	decoded.Bundle = CertBundle{}
+
+	for _, raw := range mid.Intermediates {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+
+		decoded.Bundle.Intermediates = append(decoded.Bundle.Intermediates, cert)
+	}
+
+	for _, cert := range mid.PlatformCerts {
+		decoded.Bundle.PlatformCerts = append(decoded.Bundle.PlatformCerts, cert)
+	}
+
+	decoded.Quote = nil
+
+	if mid.Quote != nil {
+		pcrs := map[uint][]byte{}
+		for index, digest := range mid.Quote.PCRs {
+			pcrs[index] = digest
+		}
+
+		decoded.Quote = &JoinQuote{
+			AKPublic: mid.Quote.AKPublic,
+			Quote: tpm_utils.Quote{
+				Quoted:    mid.Quote.Quoted,
+				Signature: mid.Quote.Signature,
+				PCRs:      pcrs,
+			},
+			EventLog: mid.Quote.EventLog,
+		}
+	}
+
+	return nil
+}
+
+type MiddleJSONCredentialCipher struct {
+	MiddleJSONHeader
+	A                 Base64URL `json:"a,omitempty"`
+	C                 Base64URL `json:"c,omitempty"`
+	WrappedCredential Base64URL `json:"wrappedCredential"`
+	IdObject          Base64URL `json:"idObject"`
+	EncA              Base64URL `json:"encA"`
+	EncC              Base64URL `json:"encC"`
+	IV                Base64URL `json:"iv"`
+}
+
+func (cipher *CredentialCipher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MiddleJSONCredentialCipher{
+		MiddleJSONHeader:  jsonHeader(JSONTypeCredentialCipher),
+		A:                 cipher.A,
+		C:                 cipher.C,
+		WrappedCredential: cipher.WrappedCredential,
+		IdObject:          cipher.IdObject,
+		EncA:              cipher.EncA,
+		EncC:              cipher.EncC,
+		IV:                cipher.IV,
+	})
+}
+
+func (decoded *CredentialCipher) UnmarshalJSON(encoded []byte) error {
+	var mid MiddleJSONCredentialCipher
+
+	err := decodeJSON(encoded, JSONTypeCredentialCipher, &mid)
+	if err != nil {
+		return err
+	}
+
+	decoded.A = mid.A
+	decoded.C = mid.C
+	decoded.WrappedCredential = mid.WrappedCredential
+	decoded.IdObject = mid.IdObject
+	decoded.EncA = mid.EncA
+	decoded.EncC = mid.EncC
+	decoded.IV = mid.IV
+
+	return nil
+}
+
+type MiddleJSONCredential struct {
+	MiddleJSONHeader
+	A Base64URL `json:"a"`
+	B Base64URL `json:"b"`
+	C Base64URL `json:"c"`
+	D Base64URL `json:"d"`
+}
+
+func (cred *Credential) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MiddleJSONCredential{
+		MiddleJSONHeader: jsonHeader(JSONTypeCredential),
+		A:                amcl_utils.EcpToBytes(cred.A),
+		B:                amcl_utils.EcpToBytes(cred.B),
+		C:                amcl_utils.EcpToBytes(cred.C),
+		D:                amcl_utils.EcpToBytes(cred.D),
+	})
+}
+
+func (decoded *Credential) UnmarshalJSON(encoded []byte) error {
+	var mid MiddleJSONCredential
+
+	err := decodeJSON(encoded, JSONTypeCredential, &mid)
+	if err != nil {
+		return err
+	}
+
+	points := []*FP256BN.ECP{}
+
+	for _, field := range []struct {
+		name string
+		buf  Base64URL
+	}{{"a", mid.A}, {"b", mid.B}, {"c", mid.C}, {"d", mid.D}} {
+		P, err := jsonToECP(field.name, field.buf)
+		if err != nil {
+			return err
+		}
+
+		points = append(points, P)
+	}
+
+	decoded.A = points[0]
+	decoded.B = points[1]
+	decoded.C = points[2]
+	decoded.D = points[3]
+
+	return nil
+}
+
+type MiddleJSONSignature struct {
+	MiddleJSONHeader
+	Proof          *SchnorrProof `json:"proof"`
+	RandomizedCred *Credential   `json:"credential"`
+	Epoch          uint32        `json:"epoch"`
+	Fingerprint    Base64URL     `json:"fingerprint,omitempty"`
+}
+
+func (signature *Signature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(MiddleJSONSignature{
+		MiddleJSONHeader: jsonHeader(JSONTypeSignature),
+		Proof:            signature.Proof,
+		RandomizedCred:   signature.RandomizedCred,
+		Epoch:            signature.Epoch,
+		Fingerprint:      signature.Fingerprint,
+	})
+}
+
+func (decoded *Signature) UnmarshalJSON(encoded []byte) error {
+	var mid MiddleJSONSignature
+
+	err := decodeJSON(encoded, JSONTypeSignature, &mid)
+	if err != nil {
+		return err
+	}
+
+	if mid.Proof == nil || mid.RandomizedCred == nil {
+		return verifyError(ErrMalformed, "signature is incomplete")
+	}
+
+	decoded.Proof = mid.Proof
+	decoded.RandomizedCred = mid.RandomizedCred
+	decoded.Epoch = mid.Epoch
+	decoded.Fingerprint = mid.Fingerprint
+
+	return nil
+}
+
+type MiddleJSONRevocationList struct {
+	MiddleJSONHeader
+	Keys []Base64URL `json:"keys"`
+}
+
+/**
+ * RevocationList is a slice of the secret keys, so it has no methods.
+ */
+func EncodeRevocationListJSON(list RevocationList) ([]byte, error) {
+	mid := MiddleJSONRevocationList{
+		MiddleJSONHeader: jsonHeader(JSONTypeRevocationList),
+		Keys:             []Base64URL{},
+	}
+
+	for _, encoded := range EncodeRevocationList(list) {
+		mid.Keys = append(mid.Keys, encoded)
+	}
+
+	return json.Marshal(mid)
+}
+
+func DecodeRevocationListJSON(encoded []byte) (RevocationList, error) {
+	var mid MiddleJSONRevocationList
+
+	err := decodeJSON(encoded, JSONTypeRevocationList, &mid)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := RevocationList{}
+
+	for i, buf := range mid.Keys {
+		key, err := jsonToBIG(fmt.Sprintf("key %v", i), buf)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,273 @@
+package ecdaa
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+type gobObject interface {
+	Encode() ([]byte, error)
+}
+
+/**
+ * Check the object is the same in the gob form after the JSON round trip.
+ */
+func checkJSONRoundTrip[T any, P interface {
+	*T
+	gobObject
+}](t *testing.T, object P) []byte {
+	encoded, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var decoded T
+
+	err = json.Unmarshal(encoded, P(&decoded))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected, err := object.Encode()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	actual, err := P(&decoded).Encode()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Fatalf("gob forms are not equal after JSON: %s", encoded)
+	}
+
+	return encoded
+}
+
+/**
+ * Check the object with the field replaced by the bytes is refused as malformed.
+ */
+func checkJSONMalformed[T any](t *testing.T, object any, field string, value []byte) {
+	encoded, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var fields map[string]any
+
+	err = json.Unmarshal(encoded, &fields)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	fields[field] = base64.RawURLEncoding.EncodeToString(value)
+
+	encoded, err = json.Marshal(fields)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var decoded T
+
+	err = json.Unmarshal(encoded, &decoded)
+	if !errors.Is(err, ErrMalformed) {
+		t.Fatalf("%v of %v bytes is not refused: %v", field, len(value), err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+
+	issuer, signer, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	seed, B, err := GenJoinSeed(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	req, _, err := GenJoinReq(seed, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	cred, err := issuer.MakeCred(req, B, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	signature, err := NewFingerprintSigner(signer, &issuer.Ipk).Sign([]byte("hoge"), []byte("fuga"), rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	reqTPM := JoinRequestTPM{
+		JoinReq: req,
+		EKCert:  testRSAEKCert(t, 1),
+		SrkName: []byte("srk"),
+		Bundle: CertBundle{
+			Intermediates: []*x509.Certificate{testRSAEKCert(t, 2)},
+			PlatformCerts: [][]byte{[]byte("platform")},
+		},
+		Quote: &JoinQuote{
+			AKPublic: []byte("ak"),
+			Quote: tpm_utils.Quote{
+				Quoted:    []byte("quoted"),
+				Signature: []byte("signature"),
+				// one PCR, since gob encodes the maps in random order
+				PCRs: map[uint][]byte{7: {0, 1, 2}},
+			},
+		},
+	}
+
+	cipher := CredentialCipher{
+		WrappedCredential: []byte("wrapped"),
+		IdObject:          []byte("id"),
+		EncA:              amcl_utils.RandomBytes(rng, 32),
+		EncC:              amcl_utils.RandomBytes(rng, 32),
+		IV:                amcl_utils.RandomBytes(rng, 12),
+	}
+
+	t.Run("ISK", func(t *testing.T) {
+		encoded := checkJSONRoundTrip(t, &issuer.Isk)
+
+		var mid MiddleJSONISK
+
+		err := json.Unmarshal(encoded, &mid)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if !mid.Secret {
+			t.Fatalf("ISK is not tagged as secret")
+		}
+	})
+
+	t.Run("IPK", func(t *testing.T) { checkJSONRoundTrip(t, &issuer.Ipk) })
+	t.Run("JoinSeed", func(t *testing.T) { checkJSONRoundTrip(t, seed) })
+	t.Run("JoinRequest", func(t *testing.T) { checkJSONRoundTrip(t, req) })
+	t.Run("JoinRequestTPM", func(t *testing.T) { checkJSONRoundTrip(t, &reqTPM) })
+	t.Run("CredentialCipher", func(t *testing.T) { checkJSONRoundTrip(t, &cipher) })
+	t.Run("Credential", func(t *testing.T) { checkJSONRoundTrip(t, cred) })
+	t.Run("SchnorrProof", func(t *testing.T) { checkJSONRoundTrip(t, signature.Proof) })
+
+	t.Run("Signature", func(t *testing.T) {
+		encoded := checkJSONRoundTrip(t, signature)
+
+		var decoded Signature
+
+		err := json.Unmarshal(encoded, &decoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = Verify([]byte("hoge"), []byte("fuga"), &decoded, &issuer.Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+
+	t.Run("RevocationList", func(t *testing.T) {
+		rl := RevocationList{amcl_utils.RandomBig(rng), amcl_utils.RandomBig(rng)}
+
+		encoded, err := EncodeRevocationListJSON(rl)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		decoded, err := DecodeRevocationListJSON(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if !bytes.Equal(bytes.Join(EncodeRevocationList(rl), nil), bytes.Join(EncodeRevocationList(decoded), nil)) {
+			t.Fatalf("revocation list is not equal")
+		}
+	})
+
+	t.Run("type", func(t *testing.T) {
+		encoded, err := json.Marshal(cred)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var ipk IPK
+
+		if json.Unmarshal(encoded, &ipk) == nil {
+			t.Fatalf("credential is decoded as IPK")
+		}
+
+		var mid MiddleJSONCredential
+
+		err = json.Unmarshal(encoded, &mid)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		mid.Version = JSONVersion + 1
+
+		encoded, err = json.Marshal(mid)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var decoded Credential
+
+		if json.Unmarshal(encoded, &decoded) == nil {
+			t.Fatalf("unknown version is decoded")
+		}
+	})
+	t.Run("malformed", func(t *testing.T) {
+		// the x-coordinate is not on the curve
+		offCurve := append([]byte{0x02}, bytes.Repeat([]byte{0xff}, 32)...)
+
+		for _, value := range [][]byte{{0}, make([]byte, 31), make([]byte, 34), offCurve} {
+			checkJSONMalformed[Credential](t, cred, "a", value)
+			checkJSONMalformed[JoinRequest](t, req, "q", value)
+			checkJSONMalformed[SchnorrProof](t, signature.Proof, "k", value)
+		}
+
+		for _, value := range [][]byte{{0}, make([]byte, 31), make([]byte, 33)} {
+			checkJSONMalformed[ISK](t, &issuer.Isk, "x", value)
+			checkJSONMalformed[IPK](t, &issuer.Ipk, "c", value)
+			checkJSONMalformed[IPK](t, &issuer.Ipk, "x", value)
+			checkJSONMalformed[JoinSeed](t, seed, "y2", value)
+			checkJSONMalformed[SchnorrProof](t, signature.Proof, "c", value)
+			checkJSONMalformed[SchnorrProof](t, signature.Proof, "n", value)
+		}
+
+		encoded, err := EncodeRevocationListJSON(RevocationList{amcl_utils.RandomBig(rng)})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var mid MiddleJSONRevocationList
+
+		err = json.Unmarshal(encoded, &mid)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		mid.Keys[0] = mid.Keys[0][1:]
+
+		encoded, err = json.Marshal(mid)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = DecodeRevocationListJSON(encoded)
+		if !errors.Is(err, ErrMalformed) {
+			t.Fatalf("truncated key is not refused: %v", err)
+		}
+	})
+}
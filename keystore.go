@@ -0,0 +1,343 @@
+package ecdaa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+/**
+ * Encrypted at-rest storage of the secrets (ISK and member keys).
+ *
+ * The secrets are encrypted with AES-256-GCM under a key derived from
+ * a password (scrypt) or a given key-encryption key, and armored as PEM
+ * blocks whose headers carry the parameters of the encryption.
+ */
+
+const (
+	PEMTypeISK      = "ECDAA ENCRYPTED ISK"
+	PEMTypeSWSigner = "ECDAA ENCRYPTED MEMBER KEY"
+)
+
+const (
+	kdfScrypt = "scrypt"
+	kdfKEK    = "kek"
+
+	defaultScryptN = 1 << 15
+	scryptR        = 8
+	scryptP        = 1
+	keySize        = 32
+	saltSize       = 16
+
+	// bounds of the parameters taken from the headers,
+	// so a hostile file cannot take the memory or the CPU time
+	maxScryptN = 1 << 20
+	maxScryptR = 32
+	maxScryptP = 16
+	// scrypt takes 128 * N * r bytes (N * r <= 2^23 is 1 GiB),
+	// and p times of its work
+	maxScryptMemory = 1 << 23
+	maxScryptWork   = 1 << 24
+)
+
+type Protector interface {
+	Seal(pemType string, plaintext []byte) (*pem.Block, error)
+	Open(block *pem.Block) ([]byte, error)
+}
+
+/**
+ * Protect with password, the key is derived with scrypt.
+ * N is the cost parameter of scrypt (default 2^15 if zero).
+ */
+type PasswordProtector struct {
+	Password []byte
+	N        int
+}
+
+/**
+ * Protect with 32-bytes key-encryption key, e.g. taken from a HSM or KMS.
+ */
+type KEKProtector struct {
+	KEK []byte
+}
+
+func zeroize(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+func sealAEAD(key []byte, pemType string, plaintext []byte, headers map[string]string) (*pem.Block, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	headers["Nonce"] = hex.EncodeToString(nonce)
+
+	return &pem.Block{
+		Type:    pemType,
+		Headers: headers,
+		Bytes:   aead.Seal(nil, nonce, plaintext, []byte(pemType)),
+	}, nil
+}
+
+func openAEAD(key []byte, block *pem.Block) ([]byte, error) {
+	nonce, err := hex.DecodeString(block.Headers["Nonce"])
+	if err != nil {
+		return nil, fmt.Errorf("nonce: %v", err)
+	}
+
+	cipherBlock, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(cipherBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("nonce size is wrong: %v", len(nonce))
+	}
+
+	plaintext, err := aead.Open(nil, nonce, block.Bytes, []byte(block.Type))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %v", err)
+	}
+
+	return plaintext, nil
+}
+
+func (protector PasswordProtector) Seal(pemType string, plaintext []byte) (*pem.Block, error) {
+	n := protector.N
+	if n == 0 {
+		n = defaultScryptN
+	}
+
+	if maxScryptN < n {
+		return nil, fmt.Errorf("scrypt parameter N is too large: %v > %v", n, maxScryptN)
+	}
+
+	salt := make([]byte, saltSize)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key(protector.Password, salt, n, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(key)
+
+	headers := map[string]string{
+		"KDF":  kdfScrypt,
+		"Salt": hex.EncodeToString(salt),
+		"N":    strconv.Itoa(n),
+		"R":    strconv.Itoa(scryptR),
+		"P":    strconv.Itoa(scryptP),
+	}
+
+	return sealAEAD(key, pemType, plaintext, headers)
+}
+
+func (protector PasswordProtector) Open(block *pem.Block) ([]byte, error) {
+	if block.Headers["KDF"] != kdfScrypt {
+		return nil, fmt.Errorf("KDF is not match: %v", block.Headers["KDF"])
+	}
+
+	salt, err := hex.DecodeString(block.Headers["Salt"])
+	if err != nil {
+		return nil, fmt.Errorf("salt: %v", err)
+	}
+
+	var params [3]int
+
+	for i, name := range []string{"N", "R", "P"} {
+		params[i], err = strconv.Atoi(block.Headers[name])
+
+		if err != nil {
+			return nil, fmt.Errorf("scrypt parameter %v: %v", name, err)
+		}
+
+		max := []int{maxScryptN, maxScryptR, maxScryptP}[i]
+
+		if params[i] < 1 || max < params[i] {
+			return nil, fmt.Errorf("scrypt parameter %v is out of range: %v", name, params[i])
+		}
+	}
+
+	memory := params[0] * params[1]
+
+	if maxScryptMemory < memory || maxScryptWork < memory*params[2] {
+		return nil, fmt.Errorf("scrypt parameters are too costly: N=%v, R=%v, P=%v", params[0], params[1], params[2])
+	}
+
+	key, err := scrypt.Key(protector.Password, salt, params[0], params[1], params[2], keySize)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(key)
+
+	return openAEAD(key, block)
+}
+
+func (protector KEKProtector) Seal(pemType string, plaintext []byte) (*pem.Block, error) {
+	if len(protector.KEK) != keySize {
+		return nil, fmt.Errorf("KEK size must be %v: %v", keySize, len(protector.KEK))
+	}
+
+	return sealAEAD(protector.KEK, pemType, plaintext, map[string]string{"KDF": kdfKEK})
+}
+
+func (protector KEKProtector) Open(block *pem.Block) ([]byte, error) {
+	if block.Headers["KDF"] != kdfKEK {
+		return nil, fmt.Errorf("KDF is not match: %v", block.Headers["KDF"])
+	}
+
+	if len(protector.KEK) != keySize {
+		return nil, fmt.Errorf("KEK size must be %v: %v", keySize, len(protector.KEK))
+	}
+
+	return openAEAD(protector.KEK, block)
+}
+
+/**
+ * Encrypt and armor the plaintext.
+ */
+func Armor(pemType string, plaintext []byte, protector Protector) ([]byte, error) {
+	block, err := protector.Seal(pemType, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
+
+/**
+ * Dearmor and decrypt the armored data of the type.
+ */
+func Dearmor(pemType string, armored []byte, protector Protector) ([]byte, error) {
+	block, _ := pem.Decode(armored)
+	if block == nil {
+		return nil, fmt.Errorf("PEM block is not found")
+	}
+
+	if block.Type != pemType {
+		return nil, fmt.Errorf("PEM type is not match: %v != %v", block.Type, pemType)
+	}
+
+	return protector.Open(block)
+}
+
+func (isk *ISK) EncryptedEncode(protector Protector) ([]byte, error) {
+	encoded, err := isk.Encode()
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(encoded)
+
+	return Armor(PEMTypeISK, encoded, protector)
+}
+
+func (isk *ISK) EncryptedDecode(armored []byte, protector Protector) error {
+	encoded, err := Dearmor(PEMTypeISK, armored, protector)
+	if err != nil {
+		return err
+	}
+	defer zeroize(encoded)
+
+	return isk.Decode(encoded)
+}
+
+func (signer *SWSigner) EncryptedEncode(protector Protector) ([]byte, error) {
+	encoded, err := signer.Encode()
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(encoded)
+
+	return Armor(PEMTypeSWSigner, encoded, protector)
+}
+
+func (signer *SWSigner) EncryptedDecode(armored []byte, protector Protector) error {
+	encoded, err := Dearmor(PEMTypeSWSigner, armored, protector)
+	if err != nil {
+		return err
+	}
+	defer zeroize(encoded)
+
+	return signer.Decode(encoded)
+}
+
+func SaveISK(path string, isk *ISK, protector Protector) error {
+	armored, err := isk.EncryptedEncode(protector)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, armored, 0600)
+}
+
+func LoadISK(path string, protector Protector) (*ISK, error) {
+	var isk ISK
+
+	armored, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = isk.EncryptedDecode(armored, protector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &isk, nil
+}
+
+func SaveSWSigner(path string, signer *SWSigner, protector Protector) error {
+	armored, err := signer.EncryptedEncode(protector)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, armored, 0600)
+}
+
+func LoadSWSigner(path string, protector Protector) (*SWSigner, error) {
+	var signer SWSigner
+
+	armored, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = signer.EncryptedDecode(armored, protector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signer, nil
+}
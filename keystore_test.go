@@ -0,0 +1,127 @@
+package ecdaa
+
+import (
+	"bytes"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestKeystoreISK(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	isk := RandomISK(rng)
+
+	path := filepath.Join(t.TempDir(), "isk.pem")
+	protector := PasswordProtector{Password: []byte("password"), N: 1 << 10}
+
+	err := SaveISK(path, &isk, protector)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	t.Run("load_correct", func(t *testing.T) {
+		loaded, err := LoadISK(path, protector)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if isk.X.ToString() != loaded.X.ToString() || isk.Y.ToString() != loaded.Y.ToString() {
+			t.Fatalf("ISK is not equal")
+		}
+	})
+
+	t.Run("load_wrong_password", func(t *testing.T) {
+		_, err := LoadISK(path, PasswordProtector{Password: []byte("wrong")})
+		if err == nil {
+			t.Fatalf("ISK is loaded with wrong password")
+		}
+	})
+
+	t.Run("load_hostile_parameters", func(t *testing.T) {
+		armored, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		for name, value := range map[string]string{"N": "1073741824", "R": "1024", "P": "0"} {
+			block, _ := pem.Decode(armored)
+			block.Headers[name] = value
+
+			_, err := Dearmor(PEMTypeISK, pem.EncodeToMemory(block), protector)
+			if err == nil {
+				t.Fatalf("ISK is loaded with scrypt parameter %v = %v", name, value)
+			}
+		}
+
+		// each parameter is under its bound, but the product is not
+		for _, params := range []map[string]string{
+			{"N": "1048576", "R": "16", "P": "1"},
+			{"N": "262144", "R": "16", "P": "16"},
+		} {
+			block, _ := pem.Decode(armored)
+			for name, value := range params {
+				block.Headers[name] = value
+			}
+
+			_, err := Dearmor(PEMTypeISK, pem.EncodeToMemory(block), protector)
+			if err == nil {
+				t.Fatalf("ISK is loaded with scrypt parameters %v", params)
+			}
+		}
+	})
+
+	t.Run("load_wrong_type", func(t *testing.T) {
+		_, err := LoadSWSigner(path, protector)
+		if err == nil {
+			t.Fatalf("ISK is loaded as member key")
+		}
+	})
+}
+
+func TestKeystoreSWSigner(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+
+	issuer, signer, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	kek := amcl_utils.RandomBytes(rng, 32)
+	protector := KEKProtector{KEK: kek}
+
+	armored, err := signer.EncryptedEncode(protector)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if bytes.Contains(armored, amcl_utils.BigToBytes(signer.sk)) {
+		t.Fatalf("secret key is not encrypted")
+	}
+
+	var loaded SWSigner
+
+	err = loaded.EncryptedDecode(armored, protector)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	signature, err := loaded.Sign([]byte("hoge"), []byte("fuga"), rng)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	err = Verify([]byte("hoge"), []byte("fuga"), signature, &issuer.Ipk, RevocationList{})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	wrong := KEKProtector{KEK: amcl_utils.RandomBytes(rng, 32)}
+
+	err = loaded.EncryptedDecode(armored, wrong)
+	if err == nil {
+		t.Fatalf("member key is loaded with wrong KEK")
+	}
+}
@@ -0,0 +1,156 @@
+package ecdaa
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+)
+
+/**
+ * k-times anonymous authentication.
+ *
+ * A member signs on the basename of (service, epoch, slot) with one of
+ * the k slots of the epoch. The pseudonyms of the different slots and epochs
+ * are unlinkable, but signing twice on a slot gives the same pseudonym,
+ * so the verifier can limit each member to k signatures per epoch.
+ */
+
+const kTimesBasenamePrefix = "ECDAA-KTIMES"
+
+/**
+ * Basename of the slot of the service in the epoch.
+ */
+func KTimesBasename(service []byte, epoch uint64, slot uint32) []byte {
+	basename := []byte(kTimesBasenamePrefix)
+	basename = binary.BigEndian.AppendUint32(basename, uint32(len(service)))
+	basename = append(basename, service...)
+	basename = binary.BigEndian.AppendUint64(basename, epoch)
+
+	return binary.BigEndian.AppendUint32(basename, slot)
+}
+
+/**
+ * Signature on the slot of the epoch.
+ */
+type KTimesSignature struct {
+	Signature *Signature
+	Epoch     uint64
+	Slot      uint32
+}
+
+/**
+ * Signer which uses the slots of the epoch in order
+ * and refuses to sign more than K times in an epoch.
+ *
+ * The used slots are kept only in memory, so a signer restarted in the epoch
+ * reuses the slots and its signatures are rejected by the verifier.
+ */
+type KTimesSigner struct {
+	signer  Signer
+	service []byte
+	k       uint32
+	window  time.Duration
+	epoch   uint64
+	next    uint32
+	mu      sync.Mutex
+}
+
+func NewKTimesSigner(signer Signer, service []byte, k uint32, window time.Duration) *KTimesSigner {
+	return &KTimesSigner{
+		signer:  signer,
+		service: service,
+		k:       k,
+		window:  window,
+	}
+}
+
+/**
+ * The number of the signatures left in the epoch of the time.
+ */
+func (signer *KTimesSigner) Remaining(now time.Time) uint32 {
+	signer.mu.Lock()
+	defer signer.mu.Unlock()
+
+	if EpochOf(now, signer.window) != signer.epoch {
+		return signer.k
+	}
+
+	return signer.k - signer.next
+}
+
+func (signer *KTimesSigner) Sign(message []byte, now time.Time, rng *core.RAND) (*KTimesSignature, error) {
+	signer.mu.Lock()
+	defer signer.mu.Unlock()
+
+	epoch := EpochOf(now, signer.window)
+	if epoch != signer.epoch {
+		signer.epoch = epoch
+		signer.next = 0
+	}
+
+	if signer.next >= signer.k {
+		return nil, fmt.Errorf("all %v slots of epoch %v are used", signer.k, epoch)
+	}
+
+	slot := signer.next
+
+	signature, err := signer.signer.Sign(message, KTimesBasename(signer.service, epoch, slot), rng)
+	if err != nil {
+		return nil, err
+	}
+
+	signer.next++
+
+	return &KTimesSignature{
+		Signature: signature,
+		Epoch:     epoch,
+		Slot:      slot,
+	}, nil
+}
+
+/**
+ * Verifier which accepts at most K signatures per member in an epoch,
+ * by rejecting the signatures whose pseudonym is already seen on the slot.
+ */
+type KTimesVerifier struct {
+	service []byte
+	k       uint32
+	window  time.Duration
+	linker  *Linker
+}
+
+func NewKTimesVerifier(service []byte, k uint32, window time.Duration, store PseudonymStore) *KTimesVerifier {
+	return &KTimesVerifier{
+		service: service,
+		k:       k,
+		window:  window,
+		linker:  NewLinker(store, window),
+	}
+}
+
+func (verifier *KTimesVerifier) Verify(message []byte, signature *KTimesSignature, ipk *IPK, rl RevocationList, now time.Time) error {
+	epoch := EpochOf(now, verifier.window)
+	if signature.Epoch != epoch {
+		return fmt.Errorf("signature is in epoch %v, not %v", signature.Epoch, epoch)
+	}
+
+	if signature.Slot >= verifier.k {
+		return fmt.Errorf("slot %v is out of %v slots", signature.Slot, verifier.k)
+	}
+
+	basename := KTimesBasename(verifier.service, signature.Epoch, signature.Slot)
+
+	link, err := verifier.linker.Link(message, basename, signature.Signature, ipk, rl, now)
+	if err != nil {
+		return err
+	}
+
+	if link.Seen {
+		return fmt.Errorf("slot %v of epoch %v is reused", signature.Slot, signature.Epoch)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package ecdaa
+
+import (
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestKTimes(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	message := []byte("hoge")
+	service := []byte("fuga")
+	now := time.Now()
+
+	issuer, sw, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	signer := NewKTimesSigner(sw, service, 2, time.Hour)
+	verifier := NewKTimesVerifier(service, 2, time.Hour, NewMemoryPseudonymStore())
+
+	signatures := []*KTimesSignature{}
+
+	for i := 0; i < 2; i++ {
+		signature, err := signer.Sign(message, now, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		encoded, err := signature.Encode()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var decoded KTimesSignature
+
+		err = decoded.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = verifier.Verify(message, &decoded, &issuer.Ipk, RevocationList{}, now)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		signatures = append(signatures, signature)
+	}
+
+	if signatures[0].Signature.Proof.K.Equals(signatures[1].Signature.Proof.K) {
+		t.Fatalf("pseudonyms of the slots are linkable")
+	}
+
+	_, err = signer.Sign(message, now, rng)
+	if err == nil {
+		t.Fatalf("signed beyond k")
+	}
+
+	t.Run("slot_reuse", func(t *testing.T) {
+		basename := KTimesBasename(service, signatures[0].Epoch, 0)
+
+		reused, err := sw.Sign(message, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		signature := KTimesSignature{
+			Signature: reused,
+			Epoch:     signatures[0].Epoch,
+			Slot:      0,
+		}
+
+		if verifier.Verify(message, &signature, &issuer.Ipk, RevocationList{}, now) == nil {
+			t.Fatalf("reused slot is verified")
+		}
+	})
+
+	t.Run("slot_out_of_k", func(t *testing.T) {
+		basename := KTimesBasename(service, signatures[0].Epoch, 2)
+
+		extra, err := sw.Sign(message, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		signature := KTimesSignature{
+			Signature: extra,
+			Epoch:     signatures[0].Epoch,
+			Slot:      2,
+		}
+
+		if verifier.Verify(message, &signature, &issuer.Ipk, RevocationList{}, now) == nil {
+			t.Fatalf("slot out of k is verified")
+		}
+	})
+
+	t.Run("next_epoch", func(t *testing.T) {
+		next := now.Add(time.Hour)
+
+		if signer.Remaining(next) != 2 {
+			t.Fatalf("slots are not reset")
+		}
+
+		signature, err := signer.Sign(message, next, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if signature.Slot != 0 {
+			t.Fatalf("slot %v is used first", signature.Slot)
+		}
+
+		if verifier.Verify(message, signature, &issuer.Ipk, RevocationList{}, now) == nil {
+			t.Fatalf("signature of the next epoch is verified")
+		}
+
+		err = verifier.Verify(message, signature, &issuer.Ipk, RevocationList{}, next)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+}
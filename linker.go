@@ -0,0 +1,245 @@
+package ecdaa
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * Backend of the Linker which keeps the pseudonyms seen on each basename.
+ * A pseudonym is the encoded K of a linkable signature, which is the same
+ * for every signature of a member on the basename.
+ */
+type PseudonymStore interface {
+	// record a signature in the epoch, and return whether the pseudonym
+	// was seen before and the number of the signatures in the epoch
+	Record(basename, pseudonym []byte, epoch uint64) (bool, uint64, error)
+	Seen(basename, pseudonym []byte) (bool, error)
+	Count(basename, pseudonym []byte, epoch uint64) (uint64, error)
+	Block(basename, pseudonym []byte) error
+	Unblock(basename, pseudonym []byte) error
+	Blocked(basename, pseudonym []byte) (bool, error)
+	Close() error
+}
+
+/**
+ * The pseudonym of the signature on the basename.
+ */
+func Pseudonym(basename []byte, signature *Signature) ([]byte, error) {
+	if basename == nil || signature.Proof.K == nil {
+		return nil, fmt.Errorf("signature has no pseudonym")
+	}
+
+	return amcl_utils.EcpToBytes(signature.Proof.K), nil
+}
+
+/**
+ * Index of the time window the time is in, or 0 if window is 0.
+ */
+func EpochOf(now time.Time, window time.Duration) uint64 {
+	if window <= 0 {
+		return 0
+	}
+
+	return uint64(now.UnixNano() / int64(window))
+}
+
+// basename and pseudonym are joined with the length,
+// so the keys of different basenames never collide
+func pseudonymKey(basename, pseudonym []byte) []byte {
+	key := binary.BigEndian.AppendUint32(nil, uint32(len(basename)))
+	key = append(key, basename...)
+
+	return append(key, pseudonym...)
+}
+
+/**
+ * Result of linking a signature.
+ * Seen is whether the pseudonym was seen before this signature,
+ * and Count is the number of the signatures in the epoch including this one.
+ */
+type Link struct {
+	Pseudonym []byte
+	Epoch     uint64
+	Seen      bool
+	Count     uint64
+}
+
+/**
+ * Verifier-side service which links the signatures on a basename
+ * by their pseudonyms. The epochs are the time windows of Window.
+ */
+type Linker struct {
+	Store  PseudonymStore
+	Window time.Duration
+}
+
+func NewLinker(store PseudonymStore, window time.Duration) *Linker {
+	return &Linker{
+		Store:  store,
+		Window: window,
+	}
+}
+
+/**
+ * Verify the linkable signature, reject it if the pseudonym is blocked,
+ * and record it.
+ */
+func (linker *Linker) Link(message, basename []byte, signature *Signature, ipk *IPK, rl RevocationList, now time.Time) (*Link, error) {
+	pseudonym, err := Pseudonym(basename, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	err = Verify(message, basename, signature, ipk, rl)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked, err := linker.Store.Blocked(basename, pseudonym)
+	if err != nil {
+		return nil, err
+	}
+
+	if blocked {
+		return nil, verifyError(ErrPseudonymRevoked, "the pseudonym is blocked")
+	}
+
+	epoch := EpochOf(now, linker.Window)
+
+	seen, count, err := linker.Store.Record(basename, pseudonym, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Link{
+		Pseudonym: pseudonym,
+		Epoch:     epoch,
+		Seen:      seen,
+		Count:     count,
+	}, nil
+}
+
+/**
+ * Whether the pseudonym has been seen under the basename.
+ */
+func (linker *Linker) Seen(basename, pseudonym []byte) (bool, error) {
+	return linker.Store.Seen(basename, pseudonym)
+}
+
+/**
+ * The number of the signatures of the pseudonym in the epoch of the time.
+ */
+func (linker *Linker) Count(basename, pseudonym []byte, now time.Time) (uint64, error) {
+	return linker.Store.Count(basename, pseudonym, EpochOf(now, linker.Window))
+}
+
+func (linker *Linker) Block(basename, pseudonym []byte) error {
+	return linker.Store.Block(basename, pseudonym)
+}
+
+func (linker *Linker) Unblock(basename, pseudonym []byte) error {
+	return linker.Store.Unblock(basename, pseudonym)
+}
+
+type pseudonymEntry struct {
+	counts  map[uint64]uint64
+	blocked bool
+}
+
+/**
+ * In-memory PseudonymStore, which is lost when the process ends.
+ */
+type MemoryPseudonymStore struct {
+	entries map[string]*pseudonymEntry
+	mu      sync.Mutex
+}
+
+func NewMemoryPseudonymStore() *MemoryPseudonymStore {
+	return &MemoryPseudonymStore{
+		entries: map[string]*pseudonymEntry{},
+	}
+}
+
+func (store *MemoryPseudonymStore) entry(basename, pseudonym []byte) *pseudonymEntry {
+	key := string(pseudonymKey(basename, pseudonym))
+
+	entry, ok := store.entries[key]
+	if !ok {
+		entry = &pseudonymEntry{counts: map[uint64]uint64{}}
+		store.entries[key] = entry
+	}
+
+	return entry
+}
+
+func (store *MemoryPseudonymStore) Record(basename, pseudonym []byte, epoch uint64) (bool, uint64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entry := store.entry(basename, pseudonym)
+	seen := len(entry.counts) != 0
+
+	entry.counts[epoch]++
+
+	return seen, entry.counts[epoch], nil
+}
+
+func (store *MemoryPseudonymStore) Seen(basename, pseudonym []byte) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entry, ok := store.entries[string(pseudonymKey(basename, pseudonym))]
+
+	return ok && len(entry.counts) != 0, nil
+}
+
+func (store *MemoryPseudonymStore) Count(basename, pseudonym []byte, epoch uint64) (uint64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entry, ok := store.entries[string(pseudonymKey(basename, pseudonym))]
+	if !ok {
+		return 0, nil
+	}
+
+	return entry.counts[epoch], nil
+}
+
+func (store *MemoryPseudonymStore) Block(basename, pseudonym []byte) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.entry(basename, pseudonym).blocked = true
+
+	return nil
+}
+
+func (store *MemoryPseudonymStore) Unblock(basename, pseudonym []byte) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entry, ok := store.entries[string(pseudonymKey(basename, pseudonym))]
+	if ok {
+		entry.blocked = false
+	}
+
+	return nil
+}
+
+func (store *MemoryPseudonymStore) Blocked(basename, pseudonym []byte) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entry, ok := store.entries[string(pseudonymKey(basename, pseudonym))]
+
+	return ok && entry.blocked, nil
+}
+
+func (store *MemoryPseudonymStore) Close() error {
+	return nil
+}
@@ -0,0 +1,140 @@
+package ecdaa
+
+import (
+	"encoding/binary"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltSeenBucket    = []byte("seen")
+	boltCountsBucket  = []byte("counts")
+	boltBlockedBucket = []byte("blocked")
+)
+
+/**
+ * File-backed PseudonymStore on BoltDB.
+ *
+ * seen:    pseudonym key -> empty
+ * counts:  pseudonym key || epoch (big endian) -> count (big endian)
+ * blocked: pseudonym key -> empty
+ */
+type BoltPseudonymStore struct {
+	db *bolt.DB
+}
+
+/**
+ * Open the database file, creating it if it does not exist.
+ */
+func OpenBoltPseudonymStore(path string) (*BoltPseudonymStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltSeenBucket, boltCountsBucket, boltBlockedBucket} {
+			_, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltPseudonymStore{db: db}, nil
+}
+
+func countKey(basename, pseudonym []byte, epoch uint64) []byte {
+	return binary.BigEndian.AppendUint64(pseudonymKey(basename, pseudonym), epoch)
+}
+
+func (store *BoltPseudonymStore) Record(basename, pseudonym []byte, epoch uint64) (bool, uint64, error) {
+	var seen bool
+	var count uint64
+
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		key := pseudonymKey(basename, pseudonym)
+		seenBucket := tx.Bucket(boltSeenBucket)
+
+		seen = seenBucket.Get(key) != nil
+		if !seen {
+			err := seenBucket.Put(key, []byte{})
+			if err != nil {
+				return err
+			}
+		}
+
+		counts := tx.Bucket(boltCountsBucket)
+		ckey := countKey(basename, pseudonym, epoch)
+
+		if value := counts.Get(ckey); value != nil {
+			count = binary.BigEndian.Uint64(value)
+		}
+
+		count++
+
+		return counts.Put(ckey, binary.BigEndian.AppendUint64(nil, count))
+	})
+
+	return seen, count, err
+}
+
+func (store *BoltPseudonymStore) Seen(basename, pseudonym []byte) (bool, error) {
+	var seen bool
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		seen = tx.Bucket(boltSeenBucket).Get(pseudonymKey(basename, pseudonym)) != nil
+		return nil
+	})
+
+	return seen, err
+}
+
+func (store *BoltPseudonymStore) Count(basename, pseudonym []byte, epoch uint64) (uint64, error) {
+	var count uint64
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(boltCountsBucket).Get(countKey(basename, pseudonym, epoch))
+		if value != nil {
+			count = binary.BigEndian.Uint64(value)
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+func (store *BoltPseudonymStore) Block(basename, pseudonym []byte) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBlockedBucket).Put(pseudonymKey(basename, pseudonym), []byte{})
+	})
+}
+
+func (store *BoltPseudonymStore) Unblock(basename, pseudonym []byte) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBlockedBucket).Delete(pseudonymKey(basename, pseudonym))
+	})
+}
+
+func (store *BoltPseudonymStore) Blocked(basename, pseudonym []byte) (bool, error) {
+	var blocked bool
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		blocked = tx.Bucket(boltBlockedBucket).Get(pseudonymKey(basename, pseudonym)) != nil
+		return nil
+	})
+
+	return blocked, err
+}
+
+func (store *BoltPseudonymStore) Close() error {
+	return store.db.Close()
+}
@@ -0,0 +1,131 @@
+package ecdaa
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestLinker(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	message := []byte("hoge")
+	basename := []byte("fuga")
+	now := time.Now()
+
+	issuer, signer, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	other, err := ExampleJoin(issuer, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	bolt, err := OpenBoltPseudonymStore(filepath.Join(t.TempDir(), "pseudonyms.db"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	stores := map[string]PseudonymStore{
+		"memory": NewMemoryPseudonymStore(),
+		"bolt":   bolt,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			defer store.Close()
+
+			linker := NewLinker(store, time.Hour)
+
+			for i := uint64(1); i <= 2; i++ {
+				signature, err := signer.Sign(message, basename, rng)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+
+				link, err := linker.Link(message, basename, signature, &issuer.Ipk, RevocationList{}, now)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+
+				if link.Seen != (i != 1) || link.Count != i {
+					t.Fatalf("link %v: seen %v, count %v", i, link.Seen, link.Count)
+				}
+			}
+
+			signature, err := signer.Sign(message, basename, rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			link, err := linker.Link(message, basename, signature, &issuer.Ipk, RevocationList{}, now.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			if !link.Seen || link.Count != 1 {
+				t.Fatalf("next epoch: seen %v, count %v", link.Seen, link.Count)
+			}
+
+			count, err := linker.Count(basename, link.Pseudonym, now)
+			if err != nil || count != 2 {
+				t.Fatalf("count %v: %v", count, err)
+			}
+
+			seen, err := linker.Seen([]byte("piyo"), link.Pseudonym)
+			if err != nil || seen {
+				t.Fatalf("seen under other basename: %v", err)
+			}
+
+			unlinkable, err := signer.Sign(message, nil, rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			_, err = linker.Link(message, nil, unlinkable, &issuer.Ipk, RevocationList{}, now)
+			if err == nil {
+				t.Fatalf("unlinkable signature is linked")
+			}
+
+			err = linker.Block(basename, link.Pseudonym)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			_, err = linker.Link(message, basename, signature, &issuer.Ipk, RevocationList{}, now)
+			expectReason(t, err, ErrPseudonymRevoked, -1)
+
+			otherSignature, err := other.Sign(message, basename, rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			link, err = linker.Link(message, basename, otherSignature, &issuer.Ipk, RevocationList{}, now)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			if link.Seen || link.Count != 1 {
+				t.Fatalf("other member: seen %v, count %v", link.Seen, link.Count)
+			}
+
+			pseudonym, err := Pseudonym(basename, signature)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			err = linker.Unblock(basename, pseudonym)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			_, err = linker.Link(message, basename, signature, &issuer.Ipk, RevocationList{}, now)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+		})
+	}
+}
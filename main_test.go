@@ -26,6 +26,24 @@ func TestTPM(t *testing.T) {
 	testSignAndVerify(t, signer, issuer)
 }
 
+func TestSealed(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	password := []byte("piyo")
+
+	tpm, err := tpm_utils.OpenTPM(password, tpm_utils.TPM_PATH)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer tpm.Close()
+
+	issuer, signer, err := ExampleSealedInitialize(tpm, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	testSignAndVerify(t, signer, issuer)
+}
+
 func TestSW(t *testing.T) {
 	rng := amcl_utils.InitRandom()
 
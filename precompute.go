@@ -0,0 +1,160 @@
+package ecdaa
+
+import (
+	"sync"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * Offline phase of a signature on a basename: the randomized credential
+ * and the commitment of the Schnorr proof.
+ */
+type precomputation struct {
+	cred *Credential
+	B    *FP256BN.ECP
+	r    *FP256BN.BIG
+	E    *FP256BN.ECP
+	L    *FP256BN.ECP
+	K    *FP256BN.ECP
+}
+
+func precompute(signer *SWSigner, B *FP256BN.ECP, linkable bool, rng *core.RAND) *precomputation {
+	cred := RandomizeCred(signer.cred, rng)
+	r, E, L, K := commit(signer.sk, B, cred.B, rng, linkable)
+
+	return &precomputation{
+		cred: cred,
+		B:    B,
+		r:    r,
+		E:    E,
+		L:    L,
+		K:    K,
+	}
+}
+
+// nil (unlinkable) and empty basenames are different
+func poolKey(basename []byte) string {
+	if basename == nil {
+		return ""
+	}
+
+	return "b" + string(basename)
+}
+
+/**
+ * Software signer which precomputes the signatures on the basenames
+ * in the background, so that Sign costs only the hashes and a few field
+ * operations while the pool is not empty.
+ *
+ * Each precomputation is taken from the pool by one Sign and its nonce
+ * is zeroized after the use, so it is never used twice. Sign falls back to
+ * the online computation when the pool of the basename is empty.
+ */
+type PrecomputedSigner struct {
+	signer SWSigner
+	pools  map[string]chan *precomputation
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	closed sync.Once
+}
+
+/**
+ * Start the background workers filling size precomputations for each
+ * of the basenames (nil for the unlinkable signatures).
+ */
+func NewPrecomputedSigner(signer SWSigner, size int, basenames [][]byte) (*PrecomputedSigner, error) {
+	precomputed := PrecomputedSigner{
+		signer: signer,
+		pools:  map[string]chan *precomputation{},
+		stop:   make(chan struct{}),
+	}
+
+	for _, basename := range basenames {
+		key := poolKey(basename)
+		if _, ok := precomputed.pools[key]; ok {
+			continue
+		}
+
+		B, err := basenamePoint(basename)
+		if err != nil {
+			precomputed.Close()
+			return nil, err
+		}
+
+		pool := make(chan *precomputation, size)
+		precomputed.pools[key] = pool
+
+		precomputed.wg.Add(1)
+		go precomputed.fill(pool, B, basename != nil)
+	}
+
+	return &precomputed, nil
+}
+
+func (signer *PrecomputedSigner) fill(pool chan *precomputation, B *FP256BN.ECP, linkable bool) {
+	defer signer.wg.Done()
+
+	// core.RAND is not safe for concurrent use
+	rng := amcl_utils.InitRandom()
+
+	for {
+		pre := precompute(&signer.signer, B, linkable, rng)
+
+		select {
+		case pool <- pre:
+		case <-signer.stop:
+			zeroizeBIG(pre.r)
+			return
+		}
+	}
+}
+
+/**
+ * The number of the precomputations left for the basename.
+ */
+func (signer *PrecomputedSigner) Available(basename []byte) int {
+	pool, ok := signer.pools[poolKey(basename)]
+	if !ok {
+		return 0
+	}
+
+	return len(pool)
+}
+
+func (signer *PrecomputedSigner) Sign(message, basename []byte, rng *core.RAND) (*Signature, error) {
+	var pre *precomputation
+
+	select {
+	case pre = <-signer.pools[poolKey(basename)]:
+	default:
+		return signer.signer.Sign(message, basename, rng)
+	}
+
+	proof := respondSchnorr(message, basename, signer.signer.sk, pre.cred.B, pre.cred.D, pre.B, pre.r, pre.E, pre.L, pre.K, rng)
+	zeroizeBIG(pre.r)
+
+	return &Signature{
+		Proof:          proof,
+		RandomizedCred: pre.cred,
+	}, nil
+}
+
+/**
+ * Stop the workers and zeroize the precomputations left.
+ * It is safe to call Close more than once.
+ */
+func (signer *PrecomputedSigner) Close() {
+	signer.closed.Do(func() {
+		close(signer.stop)
+		signer.wg.Wait()
+
+		for _, pool := range signer.pools {
+			for len(pool) > 0 {
+				zeroizeBIG((<-pool).r)
+			}
+		}
+	})
+}
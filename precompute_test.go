@@ -0,0 +1,157 @@
+package ecdaa
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func waitPrecomputed(t *testing.T, signer *PrecomputedSigner, basename []byte, size int) {
+	deadline := time.Now().Add(30 * time.Second)
+
+	for signer.Available(basename) < size {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool is not filled: %v", signer.Available(basename))
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPrecomputedSigner(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	message := []byte("hoge")
+	basename := []byte("fuga")
+	size := 4
+
+	issuer, sw, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	signer, err := NewPrecomputedSigner(*sw, size, [][]byte{basename, nil})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	defer signer.Close()
+
+	for _, bsn := range [][]byte{basename, nil} {
+		name := "linkable"
+		if bsn == nil {
+			name = "unlinkable"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			waitPrecomputed(t, signer, bsn, size)
+
+			// more than the pool, so some of them are computed online
+			signatures := make([]*Signature, 2*size)
+			errs := make([]error, 2*size)
+
+			var wg sync.WaitGroup
+
+			for i := range signatures {
+				wg.Add(1)
+
+				go func(i int) {
+					defer wg.Done()
+					signatures[i], errs[i] = signer.Sign(message, bsn, amcl_utils.InitRandom())
+				}(i)
+			}
+
+			wg.Wait()
+
+			used := map[string]bool{}
+
+			for i, signature := range signatures {
+				if errs[i] != nil {
+					t.Fatalf("%v", errs[i])
+				}
+
+				err := Verify(message, bsn, signature, &issuer.Ipk, RevocationList{})
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+
+				key := string(amcl_utils.EcpToBytes(signature.RandomizedCred.A))
+				if used[key] {
+					t.Fatalf("precomputation is used twice")
+				}
+
+				used[key] = true
+			}
+		})
+	}
+
+	t.Run("other_basename", func(t *testing.T) {
+		other := []byte("piyo")
+
+		signature, err := signer.Sign(message, other, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = Verify(message, other, signature, &issuer.Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+	t.Run("close_twice", func(t *testing.T) {
+		signer.Close()
+		signer.Close()
+
+		signature, err := signer.Sign(message, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = Verify(message, basename, signature, &issuer.Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+}
+
+func BenchmarkSignPrecomputed(b *testing.B) {
+	rng := amcl_utils.InitRandom()
+	basename := []byte("fuga")
+
+	_, signer, err := ExampleInitialize(rng)
+	if err != nil {
+		b.Fatalf("%v", err)
+	}
+
+	b.Run("sign_online", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := signer.Sign([]byte{}, basename, rng)
+			if err != nil {
+				b.Fatalf("%v", err)
+			}
+		}
+	})
+
+	precomputed, err := NewPrecomputedSigner(*signer, 1024, [][]byte{basename})
+	if err != nil {
+		b.Fatalf("%v", err)
+	}
+
+	defer precomputed.Close()
+
+	b.Run("sign_precomputed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			for precomputed.Available(basename) == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			b.StartTimer()
+
+			_, err := precomputed.Sign([]byte{}, basename, rng)
+			if err != nil {
+				b.Fatalf("%v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,554 @@
+package ecdaa
+
+import (
+	"fmt"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * Predicate proofs over hidden attributes.
+ *
+ * The hidden attribute m is committed as C = G^m * H^r and the commitment
+ * is linked to the attribute signature by sharing the response of m.
+ * Range predicates decompose the difference to the bound into bits
+ * committed with OR-proofs, and set membership is an OR-proof over the set.
+ *
+ * The range predicates are over the attributes and the bounds less than
+ * 2^RangeProofBits, so that neither of them wraps around p: attribute >= Bound
+ * shows attribute - Bound < 2^RangeProofBits, and attribute <= Bound shows
+ * both Bound - attribute < 2^RangeProofBits and attribute < 2^RangeProofBits.
+ */
+
+type PredicateType int
+
+const (
+	// attribute >= Bound
+	PredicateGreaterOrEqual PredicateType = iota
+	// attribute <= Bound
+	PredicateLessOrEqual
+	// attribute ∈ Set
+	PredicateInSet
+)
+
+/**
+ * Bit length of the difference between the attribute and the bound.
+ */
+const RangeProofBits = 64
+
+type Predicate struct {
+	Index int
+	Type  PredicateType
+	Bound *FP256BN.BIG
+	Set   []*FP256BN.BIG
+}
+
+/**
+ * Non-interactive OR-proof of knowledge of r s.t. Y_i = H^r for some i.
+ */
+type OrProof struct {
+	C []*FP256BN.BIG
+	Z []*FP256BN.BIG
+}
+
+type PredicateProof struct {
+	Predicate Predicate
+
+	// commitment to the attribute (set membership only,
+	// derived from Bits for range predicates)
+	C *FP256BN.ECP
+	// response of the commitment randomness
+	ZR *FP256BN.BIG
+
+	Bits      []*FP256BN.ECP
+	BitProofs []*OrProof
+	SetProof  *OrProof
+
+	// bits of the attribute itself (attribute <= Bound only),
+	// committed to the same commitment as Bits
+	LowerBits      []*FP256BN.ECP
+	LowerBitProofs []*OrProof
+}
+
+type predicateWitness struct {
+	r          *FP256BN.BIG
+	rR         *FP256BN.BIG
+	bitRs      []*FP256BN.BIG
+	bits       []int
+	lowerBitRs []*FP256BN.BIG
+	lowerBits  []int
+	setIndex   int
+}
+
+func predicateGenerators() (*FP256BN.ECP, *FP256BN.ECP, error) {
+	G, err := generatorFromLabel([]byte("ecdaa-predicate-g"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	H, err := generatorFromLabel([]byte("ecdaa-predicate-h"))
+
+	return G, H, err
+}
+
+func (pred *Predicate) Equals(other *Predicate) bool {
+	if pred.Index != other.Index || pred.Type != other.Type {
+		return false
+	}
+
+	if pred.Type == PredicateInSet {
+		if len(pred.Set) != len(other.Set) {
+			return false
+		}
+
+		for i := range pred.Set {
+			if pred.Set[i] == nil || other.Set[i] == nil || FP256BN.Comp(pred.Set[i], other.Set[i]) != 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return pred.Bound != nil && other.Bound != nil && FP256BN.Comp(pred.Bound, other.Bound) == 0
+}
+
+/**
+ * Check the predicate is well-formed, before using its bound or set.
+ */
+func (pred *Predicate) validate() error {
+	switch pred.Type {
+	case PredicateGreaterOrEqual, PredicateLessOrEqual:
+		if pred.Bound == nil {
+			return verifyError(ErrMalformed, "bound of predicate is missing")
+		}
+
+		_, err := rangeBits(pred.Bound)
+		if err != nil {
+			return verifyError(ErrMalformed, "bound of predicate is not less than 2^%v", RangeProofBits)
+		}
+
+	case PredicateInSet:
+		if len(pred.Set) == 0 {
+			return verifyError(ErrMalformed, "set of predicate is empty")
+		}
+
+		for _, v := range pred.Set {
+			if v == nil {
+				return verifyError(ErrMalformed, "set of predicate has nil")
+			}
+		}
+
+	default:
+		return verifyError(ErrMalformed, "unknown predicate type: %v", pred.Type)
+	}
+
+	return nil
+}
+
+func (pred *Predicate) writeHash(hash *amcl_utils.Hash) {
+	hash.WriteBIG(FP256BN.NewBIGint(pred.Index), FP256BN.NewBIGint(int(pred.Type)))
+
+	if pred.Type == PredicateInSet {
+		hash.WriteBIG(pred.Set...)
+	} else {
+		hash.WriteBIG(pred.Bound)
+	}
+}
+
+/**
+ * bits of v (least significant first), or error if v >= 2^RangeProofBits
+ */
+func rangeBits(v *FP256BN.BIG) ([]int, error) {
+	buf := amcl_utils.BigToBytes(v)
+	bits := make([]int, RangeProofBits)
+
+	for i := 0; i < len(buf)-RangeProofBits/8; i++ {
+		if buf[i] != 0 {
+			return nil, fmt.Errorf("predicate is not satisfied")
+		}
+	}
+
+	for k := 0; k < RangeProofBits; k++ {
+		bits[k] = int(buf[len(buf)-1-k/8]>>(k%8)) & 1
+	}
+
+	return bits, nil
+}
+
+/**
+ * Commit to the bits of v as Bits_k = G^b_k * H^r_k, and return
+ * ρ = Σ 2^k r_k. If rho is given, r_0 is taken so that ρ equals it.
+ */
+func commitBits(v, rho *FP256BN.BIG, G, H *FP256BN.ECP, rng *core.RAND) ([]*FP256BN.ECP, []int, []*FP256BN.BIG, *FP256BN.BIG, error) {
+	bits, err := rangeBits(v)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	rs := make([]*FP256BN.BIG, len(bits))
+	sum := FP256BN.NewBIGint(0)
+	pow := FP256BN.NewBIGint(1)
+
+	for k := range bits {
+		rs[k] = amcl_utils.RandomBig(rng)
+
+		if k != 0 {
+			sum = FP256BN.Modadd(sum, FP256BN.Modmul(pow, rs[k], amcl_utils.P()), amcl_utils.P())
+		}
+
+		pow = FP256BN.Modadd(pow, pow, amcl_utils.P())
+	}
+
+	if rho != nil {
+		rs[0] = modSub(rho, sum)
+	}
+
+	commitments := make([]*FP256BN.ECP, len(bits))
+
+	for k, b := range bits {
+		commitments[k] = H.Mul(rs[k])
+		if b == 1 {
+			commitments[k].Add(G)
+		}
+	}
+
+	return commitments, bits, rs, FP256BN.Modadd(sum, rs[0], amcl_utils.P()), nil
+}
+
+/**
+ * Π Bits_k^(2^k)
+ */
+func combineBits(bits []*FP256BN.ECP) *FP256BN.ECP {
+	result := FP256BN.NewECP()
+	pow := FP256BN.NewBIGint(1)
+
+	for _, bit := range bits {
+		result.Add(bit.Mul(pow))
+		pow = FP256BN.Modadd(pow, pow, amcl_utils.P())
+	}
+
+	return result
+}
+
+/**
+ * Commitment to the attribute, C = G^m * H^r.
+ */
+func (proof *PredicateProof) commitment(G *FP256BN.ECP) *FP256BN.ECP {
+	switch proof.Predicate.Type {
+	case PredicateGreaterOrEqual:
+		// C = G^bound * Π Bits_k^(2^k)
+		C := G.Mul(proof.Predicate.Bound)
+		C.Add(combineBits(proof.Bits))
+
+		return C
+	case PredicateLessOrEqual:
+		// C = G^bound * (Π Bits_k^(2^k))^-1
+		C := G.Mul(proof.Predicate.Bound)
+		C.Sub(combineBits(proof.Bits))
+
+		return C
+	default:
+		return proof.C
+	}
+}
+
+/**
+ * Statements of the OR-proofs: Y_i = H^r for some i.
+ */
+func (proof *PredicateProof) orStatements(G *FP256BN.ECP) [][]*FP256BN.ECP {
+	var statements [][]*FP256BN.ECP
+
+	if proof.Predicate.Type == PredicateInSet {
+		var ys []*FP256BN.ECP
+
+		for _, v := range proof.Predicate.Set {
+			Y := FP256BN.NewECP()
+			Y.Copy(proof.C)
+			Y.Sub(G.Mul(v))
+			ys = append(ys, Y)
+		}
+
+		return append(statements, ys)
+	}
+
+	return bitStatements(proof.Bits, G)
+}
+
+/**
+ * Statements of the OR-proofs of the bits: Bits_k = H^r or Bits_k / G = H^r.
+ */
+func bitStatements(bits []*FP256BN.ECP, G *FP256BN.ECP) [][]*FP256BN.ECP {
+	var statements [][]*FP256BN.ECP
+
+	for _, bit := range bits {
+		Y := FP256BN.NewECP()
+		Y.Copy(bit)
+		Y.Sub(G)
+
+		statements = append(statements, []*FP256BN.ECP{bit, Y})
+	}
+
+	return statements
+}
+
+/**
+ * Commit to the attribute value for the predicate (by Signer).
+ */
+func commitPredicate(pred *Predicate, value *FP256BN.BIG, G, H *FP256BN.ECP, rng *core.RAND) (*PredicateProof, *predicateWitness, error) {
+	err := pred.validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proof := PredicateProof{Predicate: *pred}
+	witness := predicateWitness{}
+
+	switch pred.Type {
+	case PredicateGreaterOrEqual, PredicateLessOrEqual:
+		var delta *FP256BN.BIG
+
+		if pred.Type == PredicateGreaterOrEqual {
+			delta = modSub(value, pred.Bound)
+		} else {
+			delta = modSub(pred.Bound, value)
+		}
+
+		var rho *FP256BN.BIG
+
+		proof.Bits, witness.bits, witness.bitRs, rho, err = commitBits(delta, nil, G, H, rng)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if pred.Type == PredicateGreaterOrEqual {
+			witness.r = rho
+			break
+		}
+
+		// C = Π LowerBits_k^(2^k) = G^bound * (Π Bits_k^(2^k))^-1
+		witness.r = FP256BN.Modneg(rho, amcl_utils.P())
+
+		proof.LowerBits, witness.lowerBits, witness.lowerBitRs, _, err = commitBits(value, witness.r, G, H, rng)
+		if err != nil {
+			return nil, nil, err
+		}
+
+	case PredicateInSet:
+		witness.setIndex = -1
+
+		for i, v := range pred.Set {
+			if FP256BN.Comp(modSub(value, v), FP256BN.NewBIGint(0)) == 0 {
+				witness.setIndex = i
+			}
+		}
+
+		if witness.setIndex < 0 {
+			return nil, nil, fmt.Errorf("predicate is not satisfied")
+		}
+
+		witness.r = amcl_utils.RandomBig(rng)
+
+		proof.C = G.Mul(value)
+		proof.C.Add(H.Mul(witness.r))
+
+	}
+
+	witness.rR = amcl_utils.RandomBig(rng)
+
+	return &proof, &witness, nil
+}
+
+func orChallenge(context *FP256BN.BIG, statements, commitments []*FP256BN.ECP) *FP256BN.BIG {
+	hash := amcl_utils.NewHash()
+	hash.WriteBIG(context)
+	hash.WriteECP(statements...)
+	hash.WriteECP(commitments...)
+
+	return hash.SumToBIG()
+}
+
+func proveOr(context *FP256BN.BIG, statements []*FP256BN.ECP, H *FP256BN.ECP, known int, r *FP256BN.BIG, rng *core.RAND) *OrProof {
+	proof := OrProof{
+		C: make([]*FP256BN.BIG, len(statements)),
+		Z: make([]*FP256BN.BIG, len(statements)),
+	}
+
+	commitments := make([]*FP256BN.ECP, len(statements))
+	w := amcl_utils.RandomBig(rng)
+	sum := FP256BN.NewBIGint(0)
+
+	for i, Y := range statements {
+		if i == known {
+			commitments[i] = H.Mul(w)
+			continue
+		}
+
+		// simulated: T_i = H^z_i * Y_i^-c_i
+		proof.C[i] = amcl_utils.RandomBig(rng)
+		proof.Z[i] = amcl_utils.RandomBig(rng)
+
+		commitments[i] = H.Mul(proof.Z[i])
+		commitments[i].Sub(Y.Mul(proof.C[i]))
+
+		sum = FP256BN.Modadd(sum, proof.C[i], amcl_utils.P())
+	}
+
+	c := orChallenge(context, statements, commitments)
+
+	proof.C[known] = modSub(c, sum)
+	proof.Z[known] = schnorrResponse(w, proof.C[known], r)
+
+	return &proof
+}
+
+func verifyOr(context *FP256BN.BIG, statements []*FP256BN.ECP, H *FP256BN.ECP, proof *OrProof) error {
+	if len(proof.C) != len(statements) || len(proof.Z) != len(statements) {
+		return verifyError(ErrMalformed, "OR proof is malformed")
+	}
+
+	commitments := make([]*FP256BN.ECP, len(statements))
+	sum := FP256BN.NewBIGint(0)
+
+	for i, Y := range statements {
+		commitments[i] = H.Mul(proof.Z[i])
+		commitments[i].Sub(Y.Mul(proof.C[i]))
+
+		sum = FP256BN.Modadd(sum, proof.C[i], amcl_utils.P())
+	}
+
+	c := orChallenge(context, statements, commitments)
+
+	if FP256BN.Comp(c, sum) != 0 {
+		return verifyError(ErrProofInvalid, "OR proof is not valid")
+	}
+
+	return nil
+}
+
+/**
+ * Make the OR-proofs bound to the challenge of the attribute signature (by Signer).
+ */
+func (proof *PredicateProof) prove(witness *predicateWitness, c *FP256BN.BIG, G, H *FP256BN.ECP, rng *core.RAND) {
+	proof.ZR = schnorrResponse(witness.rR, c, witness.r)
+
+	statements := proof.orStatements(G)
+
+	if proof.Predicate.Type == PredicateInSet {
+		proof.SetProof = proveOr(c, statements[0], H, witness.setIndex, witness.r, rng)
+		return
+	}
+
+	for k, statement := range statements {
+		bitProof := proveOr(c, statement, H, witness.bits[k], witness.bitRs[k], rng)
+		proof.BitProofs = append(proof.BitProofs, bitProof)
+	}
+
+	for k, statement := range bitStatements(proof.LowerBits, G) {
+		bitProof := proveOr(c, statement, H, witness.lowerBits[k], witness.lowerBitRs[k], rng)
+		proof.LowerBitProofs = append(proof.LowerBitProofs, bitProof)
+	}
+}
+
+func checkBits(bits []*FP256BN.ECP, bitProofs []*OrProof) error {
+	if len(bits) != RangeProofBits || len(bitProofs) != RangeProofBits {
+		return verifyError(ErrMalformed, "range proof is malformed")
+	}
+
+	for k := range bits {
+		if bits[k] == nil || !checkOrProof(bitProofs[k], 2) {
+			return verifyError(ErrMalformed, "range proof is malformed")
+		}
+	}
+
+	return nil
+}
+
+func checkOrProof(proof *OrProof, size int) bool {
+	if proof == nil || len(proof.C) != size || len(proof.Z) != size {
+		return false
+	}
+
+	for i := range proof.C {
+		if proof.C[i] == nil || proof.Z[i] == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+/**
+ * Check the proof has all the fields of its predicate, before the verification.
+ */
+func (proof *PredicateProof) check() error {
+	err := proof.Predicate.validate()
+	if err != nil {
+		return err
+	}
+
+	if proof.ZR == nil {
+		return verifyError(ErrMalformed, "predicate proof is incomplete")
+	}
+
+	switch proof.Predicate.Type {
+	case PredicateInSet:
+		if proof.C == nil || !checkOrProof(proof.SetProof, len(proof.Predicate.Set)) {
+			return verifyError(ErrMalformed, "set membership proof is malformed")
+		}
+
+	case PredicateLessOrEqual:
+		err = checkBits(proof.LowerBits, proof.LowerBitProofs)
+		if err != nil {
+			return err
+		}
+
+		fallthrough
+	default:
+		err = checkBits(proof.Bits, proof.BitProofs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Verify the OR-proofs of the predicate (by Verifier).
+ */
+func (proof *PredicateProof) verify(c *FP256BN.BIG, G, H *FP256BN.ECP) error {
+	statements := proof.orStatements(G)
+
+	if proof.Predicate.Type == PredicateInSet {
+		return verifyOr(c, statements[0], H, proof.SetProof)
+	}
+
+	for k, statement := range statements {
+		err := verifyOr(c, statement, H, proof.BitProofs[k])
+
+		if err != nil {
+			return fmt.Errorf("range proof (bit %v): %w", k, err)
+		}
+	}
+
+	if proof.Predicate.Type != PredicateLessOrEqual {
+		return nil
+	}
+
+	if !combineBits(proof.LowerBits).Equals(proof.commitment(G)) {
+		return verifyError(ErrProofInvalid, "range proof of the attribute is not of the commitment")
+	}
+
+	for k, statement := range bitStatements(proof.LowerBits, G) {
+		err := verifyOr(c, statement, H, proof.LowerBitProofs[k])
+
+		if err != nil {
+			return fmt.Errorf("range proof (attribute bit %v): %w", k, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package ecdaa
+
+import (
+	"testing"
+
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestPredicates(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+
+	issuer, signer, _ := exampleAttributeInitialize(t, rng)
+
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	predicates := []Predicate{
+		{Index: 1, Type: PredicateGreaterOrEqual, Bound: AttributeFromUint64(42)},
+		{Index: 1, Type: PredicateLessOrEqual, Bound: AttributeFromUint64(100)},
+		{Index: 2, Type: PredicateInSet, Set: []*FP256BN.BIG{
+			AttributeFromBytes([]byte("us")),
+			AttributeFromBytes([]byte("jp")),
+		}},
+	}
+
+	signature, err := signer.SignWithPredicates(message, basename, []int{0}, predicates, rng)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	t.Run("verify_correct", func(t *testing.T) {
+		_, err := VerifyAttributesWithPredicates(message, basename, signature, &issuer.Ipk, predicates, RevocationList{})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	})
+
+	t.Run("verify_encoded", func(t *testing.T) {
+		encoded, err := signature.Encode()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var decoded AttributeSignature
+		err = decoded.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = VerifyAttributesWithPredicates(message, basename, &decoded, &issuer.Ipk, predicates, RevocationList{})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	})
+
+	t.Run("verify_predicates_not_match", func(t *testing.T) {
+		required := []Predicate{
+			{Index: 1, Type: PredicateGreaterOrEqual, Bound: AttributeFromUint64(43)},
+		}
+
+		_, err := VerifyAttributesWithPredicates(message, basename, signature, &issuer.Ipk, required, RevocationList{})
+		if err == nil {
+			t.Fatalf("verify: predicates are not match but verify say valid")
+		}
+	})
+
+	t.Run("verify_bit_modified", func(t *testing.T) {
+		encoded, err := signature.Encode()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var modified AttributeSignature
+		err = modified.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		modified.Predicates[0].BitProofs[0] = modified.Predicates[0].BitProofs[1]
+
+		_, err = VerifyAttributesWithPredicates(message, basename, &modified, &issuer.Ipk, predicates, RevocationList{})
+		expectReason(t, err, ErrProofInvalid, -1)
+	})
+
+	t.Run("sign_unsatisfied", func(t *testing.T) {
+		unsatisfied := []Predicate{
+			{Index: 1, Type: PredicateGreaterOrEqual, Bound: AttributeFromUint64(43)},
+		}
+
+		_, err := signer.SignWithPredicates(message, basename, []int{}, unsatisfied, rng)
+		if err == nil {
+			t.Fatalf("sign: predicate is not satisfied but sign succeeded")
+		}
+	})
+
+	t.Run("sign_disclosed", func(t *testing.T) {
+		_, err := signer.SignWithPredicates(message, basename, []int{1}, predicates[:1], rng)
+		if err == nil {
+			t.Fatalf("sign: predicate on disclosed attribute but sign succeeded")
+		}
+	})
+
+	decodeSignature := func(t *testing.T) *AttributeSignature {
+		encoded, err := signature.Encode()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var decoded AttributeSignature
+		err = decoded.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		return &decoded
+	}
+
+	t.Run("verify_lower_bits_missing", func(t *testing.T) {
+		modified := decodeSignature(t)
+		modified.Predicates[1].LowerBits = nil
+
+		_, err := VerifyAttributesWithPredicates(message, basename, modified, &issuer.Ipk, predicates, RevocationList{})
+		expectReason(t, err, ErrMalformed, -1)
+	})
+
+	t.Run("verify_bound_missing", func(t *testing.T) {
+		modified := decodeSignature(t)
+		modified.Predicates[0].Predicate.Bound = nil
+		modified.Predicates[2].Predicate.Set[0] = nil
+
+		_, err := VerifyAttributesWithPredicates(message, basename, modified, &issuer.Ipk, predicates, RevocationList{})
+		if err == nil {
+			t.Fatalf("verify: bound is missing but verify say valid")
+		}
+
+		_, err = VerifyAttributes(message, basename, modified, &issuer.Ipk, RevocationList{})
+		expectReason(t, err, ErrMalformed, -1)
+	})
+
+	t.Run("sign_wraparound", func(t *testing.T) {
+		G, H, err := predicateGenerators()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		// p - 1 = 100 - (101) mod p, but it is not <= 100
+		value := modSub(FP256BN.NewBIGint(0), FP256BN.NewBIGint(1))
+		pred := Predicate{Index: 1, Type: PredicateLessOrEqual, Bound: AttributeFromUint64(100)}
+
+		_, _, err = commitPredicate(&pred, value, G, H, rng)
+		if err == nil {
+			t.Fatalf("sign: attribute wraps around but sign succeeded")
+		}
+
+		pred.Bound = value
+
+		_, _, err = commitPredicate(&pred, AttributeFromUint64(42), G, H, rng)
+		if err == nil {
+			t.Fatalf("sign: bound is not less than 2^64 but sign succeeded")
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package ecdaa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+/**
+ * Platform state attested at join: a quote by the AK and the event log
+ * which explains the quoted PCR values.
+ */
+type JoinQuote struct {
+	AKPublic []byte
+	Quote    tpm_utils.Quote
+	EventLog []byte
+}
+
+/**
+ * Issuer-side hook which replays the event log against the quoted PCR values
+ * and evaluates it with the policy.
+ */
+type EventLogVerifier interface {
+	VerifyEventLog(eventLog []byte, pcrs map[uint][]byte) error
+}
+
+/**
+ * The nonce of the quote, which binds it to the join seed and the member key.
+ */
+func JoinQuoteNonce(seed *JoinSeed, Q *FP256BN.ECP) []byte {
+	hash := sha256.New()
+	hash.Write(seed.Basename)
+	hash.Write(amcl_utils.EcpToBytes(Q))
+
+	return hash.Sum(nil)
+}
+
+/**
+ * Step2. generate request for join with TPM attaching the quote of the PCRs (by Member)
+ *
+ * The credential is bound to the AK name instead of the SRK name,
+ * so the issuer learns the AK lives in the TPM of the EK by ActivateCredential.
+ * eventLog may be nil if the issuer does not require it.
+ */
+func GenJoinReqWithQuote(seed *JoinSeed, tpm *tpm_utils.TPM, pcrs []uint, eventLog []byte, rng *core.RAND) (*JoinRequestTPM, *KeyHandles, error) {
+	req, handles, err := GenJoinReqWithTPM(seed, tpm, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ak, akPublic, err := tpm.CreateAK()
+	if err != nil {
+		handles.Flush(tpm)
+		return nil, nil, err
+	}
+
+	handles.AkHandle = ak
+
+	quote, err := tpm.Quote(ak, JoinQuoteNonce(seed, req.JoinReq.Q), pcrs)
+	if err != nil {
+		handles.Flush(tpm)
+		return nil, nil, err
+	}
+
+	req.SrkName = ak.Name.Buffer
+	req.Quote = &JoinQuote{
+		AKPublic: akPublic,
+		Quote:    *quote,
+		EventLog: eventLog,
+	}
+
+	// the SRK is no longer needed once the key is loaded and bound to the AK
+	tpm.Flush(handles.SrkHandle.Handle)
+	handles.SrkHandle = nil
+
+	return req, handles, nil
+}
+
+/**
+ * Verify the quote in the join request covers PCRs, is fresh for the seed,
+ * and is signed by the AK the credential is bound to.
+ * If EventLog is set, the event log is verified against the quoted PCR values.
+ */
+type QuoteVerifier struct {
+	Seed     *JoinSeed
+	PCRs     []uint
+	EventLog EventLogVerifier
+}
+
+func (verifier *QuoteVerifier) VerifyEvidence(req *JoinRequestTPM) error {
+	if req.Quote == nil {
+		return fmt.Errorf("quote is not found")
+	}
+
+	name, err := tpm_utils.PublicName(req.Quote.AKPublic)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(name, req.SrkName) {
+		return fmt.Errorf("AK is not the key the credential is bound to")
+	}
+
+	for _, pcr := range verifier.PCRs {
+		if _, ok := req.Quote.Quote.PCRs[pcr]; !ok {
+			return fmt.Errorf("PCR %v is not quoted", pcr)
+		}
+	}
+
+	nonce := JoinQuoteNonce(verifier.Seed, req.JoinReq.Q)
+
+	err = tpm_utils.VerifyQuote(req.Quote.AKPublic, &req.Quote.Quote, nonce)
+	if err != nil {
+		return err
+	}
+
+	if verifier.EventLog != nil {
+		err = verifier.EventLog.VerifyEventLog(req.Quote.EventLog, req.Quote.Quote.PCRs)
+
+		if err != nil {
+			return fmt.Errorf("event log: %v", err)
+		}
+	}
+
+	return nil
+}
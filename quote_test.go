@@ -0,0 +1,243 @@
+package ecdaa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-tpm/tpm2"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+type testEventLog struct {
+	called bool
+	err    error
+}
+
+func (log *testEventLog) VerifyEventLog(eventLog []byte, pcrs map[uint][]byte) error {
+	log.called = true
+	return log.err
+}
+
+// software AK and quote in the format of TPM2_Quote
+func testQuote(t *testing.T, nonce []byte, pcrs map[uint][]byte) (*JoinQuote, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	public := tpm2.TPMTPublic{
+		Type:    tpm2.TPMAlgECC,
+		NameAlg: tpm2.TPMAlgSHA256,
+		ObjectAttributes: tpm2.TPMAObject{
+			FixedTPM:            true,
+			FixedParent:         true,
+			SensitiveDataOrigin: true,
+			UserWithAuth:        true,
+			Restricted:          true,
+			SignEncrypt:         true,
+		},
+		Parameters: tpm2.NewTPMUPublicParms(
+			tpm2.TPMAlgECC,
+			&tpm2.TPMSECCParms{
+				Scheme: tpm2.TPMTECCScheme{
+					Scheme: tpm2.TPMAlgECDSA,
+					Details: tpm2.NewTPMUAsymScheme(
+						tpm2.TPMAlgECDSA,
+						&tpm2.TPMSSigSchemeECDSA{HashAlg: tpm2.TPMAlgSHA256},
+					),
+				},
+				CurveID: tpm2.TPMECCNistP256,
+			},
+		),
+		Unique: tpm2.NewTPMUPublicID(
+			tpm2.TPMAlgECC,
+			&tpm2.TPMSECCPoint{
+				X: tpm2.TPM2BECCParameter{Buffer: key.X.FillBytes(make([]byte, 32))},
+				Y: tpm2.TPM2BECCParameter{Buffer: key.Y.FillBytes(make([]byte, 32))},
+			},
+		),
+	}
+
+	name, err := tpm2.ObjectName(&public)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	indices := []uint{}
+	for pcr := range pcrs {
+		indices = append(indices, pcr)
+	}
+
+	attest := tpm2.TPMSAttest{
+		Magic:     tpm2.TPMGeneratedValue,
+		Type:      tpm2.TPMSTAttestQuote,
+		ExtraData: tpm2.TPM2BData{Buffer: nonce},
+		Attested: tpm2.NewTPMUAttest(
+			tpm2.TPMSTAttestQuote,
+			&tpm2.TPMSQuoteInfo{
+				PCRSelect: tpm2.TPMLPCRSelection{
+					PCRSelections: []tpm2.TPMSPCRSelection{
+						{
+							Hash:      tpm2.TPMAlgSHA256,
+							PCRSelect: tpm2.PCClientCompatible.PCRs(indices...),
+						},
+					},
+				},
+				PCRDigest: tpm2.TPM2BDigest{Buffer: tpm_utils.PCRDigest(pcrs)},
+			},
+		),
+	}
+
+	quoted := tpm2.Marshal(attest)
+	digest := sha256.Sum256(quoted)
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	signature := tpm2.TPMTSignature{
+		SigAlg: tpm2.TPMAlgECDSA,
+		Signature: tpm2.NewTPMUSignature(
+			tpm2.TPMAlgECDSA,
+			&tpm2.TPMSSignatureECC{
+				Hash:       tpm2.TPMAlgSHA256,
+				SignatureR: tpm2.TPM2BECCParameter{Buffer: r.Bytes()},
+				SignatureS: tpm2.TPM2BECCParameter{Buffer: s.Bytes()},
+			},
+		),
+	}
+
+	quote := JoinQuote{
+		AKPublic: tpm2.Marshal(tpm2.New2B(public)),
+		Quote: tpm_utils.Quote{
+			Quoted:    quoted,
+			Signature: tpm2.Marshal(signature),
+			PCRs:      pcrs,
+		},
+		EventLog: []byte("log"),
+	}
+
+	return &quote, name.Buffer
+}
+
+func TestQuoteVerifier(t *testing.T) {
+	req, _ := testJoinRequestTPM(t)
+
+	seed := JoinSeed{Basename: []byte("basename")}
+	pcrs := map[uint][]byte{
+		0: make([]byte, 32),
+		7: sha256.New().Sum(nil),
+	}
+
+	quote, akName := testQuote(t, JoinQuoteNonce(&seed, req.JoinReq.Q), pcrs)
+	req.Quote = quote
+	req.SrkName = akName
+
+	eventLog := testEventLog{}
+	verifier := QuoteVerifier{
+		Seed:     &seed,
+		PCRs:     []uint{0, 7},
+		EventLog: &eventLog,
+	}
+
+	err := VerifyJoinEvidence(req, &verifier)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if !eventLog.called {
+		t.Fatalf("event log verifier is not called")
+	}
+
+	encoded, err := req.Encode()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var decoded JoinRequestTPM
+
+	err = decoded.Decode(encoded)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	err = VerifyJoinEvidence(&decoded, &verifier)
+	if err != nil {
+		t.Fatalf("decoded: %v", err)
+	}
+
+	t.Run("wrong_seed", func(t *testing.T) {
+		other := verifier
+		other.Seed = &JoinSeed{Basename: []byte("other")}
+
+		if VerifyJoinEvidence(req, &other) == nil {
+			t.Fatalf("verified with wrong seed")
+		}
+	})
+
+	t.Run("tampered_pcr", func(t *testing.T) {
+		tampered := *req
+		tamperedQuote := *req.Quote
+		tamperedQuote.Quote.PCRs = map[uint][]byte{0: make([]byte, 32), 7: make([]byte, 32)}
+		tampered.Quote = &tamperedQuote
+
+		if VerifyJoinEvidence(&tampered, &verifier) == nil {
+			t.Fatalf("verified with tampered PCR")
+		}
+	})
+
+	t.Run("missing_pcr", func(t *testing.T) {
+		other := verifier
+		other.PCRs = []uint{0, 4}
+
+		if VerifyJoinEvidence(req, &other) == nil {
+			t.Fatalf("verified without required PCR")
+		}
+	})
+
+	t.Run("other_ak", func(t *testing.T) {
+		unbound := *req
+		unbound.SrkName = []byte("srk")
+
+		if VerifyJoinEvidence(&unbound, &verifier) == nil {
+			t.Fatalf("verified with AK not bound to the credential")
+		}
+	})
+
+	t.Run("imported_ak", func(t *testing.T) {
+		public, err := tpm2.Unmarshal[tpm2.TPM2BPublic](req.Quote.AKPublic)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		contents, err := public.Contents()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		contents.ObjectAttributes.FixedTPM = false
+		contents.ObjectAttributes.SensitiveDataOrigin = false
+
+		imported := req.Quote.Quote
+
+		err = tpm_utils.VerifyQuote(tpm2.Marshal(tpm2.New2B(*contents)), &imported, JoinQuoteNonce(&seed, req.JoinReq.Q))
+		if err == nil {
+			t.Fatalf("verified with AK not fixed to the TPM")
+		}
+	})
+
+	t.Run("event_log_rejected", func(t *testing.T) {
+		other := verifier
+		other.EventLog = &testEventLog{err: fmt.Errorf("rejected")}
+
+		if VerifyJoinEvidence(req, &other) == nil {
+			t.Fatalf("verified with rejected event log")
+		}
+	})
+}
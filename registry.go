@@ -0,0 +1,347 @@
+package ecdaa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * Record of a join kept by the issuer.
+ * The rejected joins are recorded too with the reason in Decision.
+ * EKFingerprint and SrkName are nil for the joins without TPM.
+ */
+type JoinRecord struct {
+	ID            uint64
+	Q             *FP256BN.ECP
+	EKFingerprint []byte
+	SrkName       []byte
+	IssuedAt      time.Time
+	Epoch         uint32
+	Accepted      bool
+	Decision      string
+	Revoked       bool
+	RevokedAt     time.Time
+}
+
+/**
+ * Backend of the JoinRegistry.
+ */
+type JoinRecordStore interface {
+	// add the record assigning the ID
+	Add(record *JoinRecord) error
+	Update(record *JoinRecord) error
+	Get(id uint64) (*JoinRecord, error)
+	ByEK(fingerprint []byte) ([]JoinRecord, error)
+	All() ([]JoinRecord, error)
+	Close() error
+}
+
+/**
+ * SHA-256 of the DER of the EK certificate.
+ */
+func EKFingerprint(cert *x509.Certificate) []byte {
+	fingerprint := sha256.Sum256(cert.Raw)
+	return fingerprint[:]
+}
+
+/**
+ * Issuer-side registry of the joins, which limits the number of the
+ * credentials per EK and refuses the EKs once revoked.
+ *
+ * The credentials can not be recognized from Q in the signatures,
+ * so revoking an EK takes effect on the credentials as the issuer rotates
+ * the epoch (or on the traceable signatures opened to the records).
+ */
+type JoinRegistry struct {
+	store         JoinRecordStore
+	maxJoinsPerEK int
+	mu            sync.Mutex
+}
+
+/**
+ * maxJoinsPerEK is the number of the credentials an EK can get (unlimited if 0).
+ */
+func NewJoinRegistry(store JoinRecordStore, maxJoinsPerEK int) *JoinRegistry {
+	return &JoinRegistry{
+		store:         store,
+		maxJoinsPerEK: maxJoinsPerEK,
+	}
+}
+
+func (registry *JoinRegistry) admit(fingerprint []byte) error {
+	records, err := registry.store.ByEK(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	joins := 0
+
+	for i := range records {
+		if records[i].Revoked {
+			return fmt.Errorf("EK is revoked")
+		}
+
+		if records[i].Accepted {
+			joins++
+		}
+	}
+
+	if registry.maxJoinsPerEK != 0 && joins >= registry.maxJoinsPerEK {
+		return fmt.Errorf("EK has joined %v times", joins)
+	}
+
+	return nil
+}
+
+/**
+ * Check the join limit and the evidence, make the encrypted credential
+ * and record the decision.
+ */
+func (registry *JoinRegistry) MakeCredEncrypted(issuer *Issuer, epoch uint32, req *JoinRequestTPM, B *FP256BN.ECP, verifiers []EvidenceVerifier, now time.Time, rng *core.RAND) (*CredentialCipher, *Credential, *JoinRecord, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	record := JoinRecord{
+		Q:             req.JoinReq.Q,
+		EKFingerprint: EKFingerprint(req.EKCert),
+		SrkName:       req.SrkName,
+		IssuedAt:      now,
+		Epoch:         epoch,
+	}
+
+	err := registry.admit(record.EKFingerprint)
+	if err == nil {
+		err = VerifyJoinEvidence(req, verifiers...)
+	}
+
+	if err != nil {
+		record.Decision = err.Error()
+
+		addErr := registry.store.Add(&record)
+		if addErr != nil {
+			return nil, nil, nil, addErr
+		}
+
+		return nil, nil, nil, fmt.Errorf("join is rejected: %v", err)
+	}
+
+	cipher, cred, err := issuer.MakeCredEncrypted(req, B, rng)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	record.Accepted = true
+	record.Decision = "accepted"
+
+	err = registry.store.Add(&record)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return cipher, cred, &record, nil
+}
+
+/**
+ * Make the credential of the join without TPM and record it.
+ */
+func (registry *JoinRegistry) MakeCred(issuer *Issuer, epoch uint32, req *JoinRequest, B *FP256BN.ECP, now time.Time, rng *core.RAND) (*Credential, *JoinRecord, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	cred, err := issuer.MakeCred(req, B, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record := JoinRecord{
+		Q:        req.Q,
+		IssuedAt: now,
+		Epoch:    epoch,
+		Accepted: true,
+		Decision: "accepted",
+	}
+
+	err = registry.store.Add(&record)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cred, &record, nil
+}
+
+func (registry *JoinRegistry) Get(id uint64) (*JoinRecord, error) {
+	return registry.store.Get(id)
+}
+
+func (registry *JoinRegistry) ByEK(fingerprint []byte) ([]JoinRecord, error) {
+	return registry.store.ByEK(fingerprint)
+}
+
+func (registry *JoinRegistry) Records() ([]JoinRecord, error) {
+	return registry.store.All()
+}
+
+/**
+ * Revoke all the records of the EK, and return them.
+ * The EK can not join after that.
+ */
+func (registry *JoinRegistry) RevokeEK(fingerprint []byte, now time.Time) ([]JoinRecord, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	records, err := registry.store.ByEK(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("EK has no record")
+	}
+
+	for i := range records {
+		if records[i].Revoked {
+			continue
+		}
+
+		records[i].Revoked = true
+		records[i].RevokedAt = now
+
+		err = registry.store.Update(&records[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+/**
+ * Write the records in CSV for audits.
+ */
+func (registry *JoinRegistry) Export(w io.Writer) error {
+	records, err := registry.store.All()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+
+	err = writer.Write([]string{"id", "issued_at", "epoch", "ek_fingerprint", "srk_name", "q", "accepted", "decision", "revoked", "revoked_at"})
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		revokedAt := ""
+		if record.Revoked {
+			revokedAt = record.RevokedAt.UTC().Format(time.RFC3339)
+		}
+
+		err = writer.Write([]string{
+			strconv.FormatUint(record.ID, 10),
+			record.IssuedAt.UTC().Format(time.RFC3339),
+			strconv.FormatUint(uint64(record.Epoch), 10),
+			hex.EncodeToString(record.EKFingerprint),
+			hex.EncodeToString(record.SrkName),
+			hex.EncodeToString(amcl_utils.EcpToBytes(record.Q)),
+			strconv.FormatBool(record.Accepted),
+			record.Decision,
+			strconv.FormatBool(record.Revoked),
+			revokedAt,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+/**
+ * In-memory JoinRecordStore.
+ */
+type MemoryJoinRecordStore struct {
+	records []JoinRecord
+	mu      sync.Mutex
+}
+
+func NewMemoryJoinRecordStore() *MemoryJoinRecordStore {
+	return &MemoryJoinRecordStore{}
+}
+
+func (store *MemoryJoinRecordStore) Add(record *JoinRecord) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	record.ID = uint64(len(store.records)) + 1
+	store.records = append(store.records, *record)
+
+	return nil
+}
+
+func (store *MemoryJoinRecordStore) Update(record *JoinRecord) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if record.ID == 0 || record.ID > uint64(len(store.records)) {
+		return fmt.Errorf("join record %v is not found", record.ID)
+	}
+
+	store.records[record.ID-1] = *record
+
+	return nil
+}
+
+func (store *MemoryJoinRecordStore) Get(id uint64) (*JoinRecord, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if id == 0 || id > uint64(len(store.records)) {
+		return nil, fmt.Errorf("join record %v is not found", id)
+	}
+
+	record := store.records[id-1]
+
+	return &record, nil
+}
+
+func (store *MemoryJoinRecordStore) ByEK(fingerprint []byte) ([]JoinRecord, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	records := []JoinRecord{}
+
+	for _, record := range store.records {
+		if record.EKFingerprint != nil && bytes.Equal(record.EKFingerprint, fingerprint) {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+func (store *MemoryJoinRecordStore) All() ([]JoinRecord, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return append([]JoinRecord{}, store.records...), nil
+}
+
+func (store *MemoryJoinRecordStore) Close() error {
+	return nil
+}
@@ -0,0 +1,179 @@
+package ecdaa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltRecordsBucket = []byte("records")
+	boltEKBucket      = []byte("ek")
+)
+
+/**
+ * File-backed JoinRecordStore on BoltDB.
+ *
+ * records: ID (big endian) -> encoded record
+ * ek:      EK fingerprint || ID -> empty
+ */
+type BoltJoinRecordStore struct {
+	db *bolt.DB
+}
+
+/**
+ * Open the database file, creating it if it does not exist.
+ */
+func OpenBoltJoinRecordStore(path string) (*BoltJoinRecordStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltRecordsBucket, boltEKBucket} {
+			_, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltJoinRecordStore{db: db}, nil
+}
+
+func recordKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, id)
+}
+
+func putRecord(tx *bolt.Tx, record *JoinRecord) error {
+	encoded, err := record.Encode()
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(boltRecordsBucket).Put(recordKey(record.ID), encoded)
+}
+
+func getRecord(tx *bolt.Tx, id uint64) (*JoinRecord, error) {
+	var record JoinRecord
+
+	encoded := tx.Bucket(boltRecordsBucket).Get(recordKey(id))
+	if encoded == nil {
+		return nil, fmt.Errorf("join record %v is not found", id)
+	}
+
+	err := record.Decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (store *BoltJoinRecordStore) Add(record *JoinRecord) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		id, err := tx.Bucket(boltRecordsBucket).NextSequence()
+		if err != nil {
+			return err
+		}
+
+		record.ID = id
+
+		err = putRecord(tx, record)
+		if err != nil {
+			return err
+		}
+
+		if record.EKFingerprint == nil {
+			return nil
+		}
+
+		key := append(append([]byte{}, record.EKFingerprint...), recordKey(id)...)
+
+		return tx.Bucket(boltEKBucket).Put(key, []byte{})
+	})
+}
+
+func (store *BoltJoinRecordStore) Update(record *JoinRecord) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		_, err := getRecord(tx, record.ID)
+		if err != nil {
+			return err
+		}
+
+		return putRecord(tx, record)
+	})
+}
+
+func (store *BoltJoinRecordStore) Get(id uint64) (*JoinRecord, error) {
+	var record *JoinRecord
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		var err error
+		record, err = getRecord(tx, id)
+
+		return err
+	})
+
+	return record, err
+}
+
+func (store *BoltJoinRecordStore) ByEK(fingerprint []byte) ([]JoinRecord, error) {
+	records := []JoinRecord{}
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltEKBucket).Cursor()
+
+		for key, _ := cursor.Seek(fingerprint); key != nil && bytes.HasPrefix(key, fingerprint); key, _ = cursor.Next() {
+			if len(key) != len(fingerprint)+8 {
+				continue
+			}
+
+			record, err := getRecord(tx, binary.BigEndian.Uint64(key[len(fingerprint):]))
+			if err != nil {
+				return err
+			}
+
+			records = append(records, *record)
+		}
+
+		return nil
+	})
+
+	return records, err
+}
+
+func (store *BoltJoinRecordStore) All() ([]JoinRecord, error) {
+	records := []JoinRecord{}
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltRecordsBucket).ForEach(func(_, encoded []byte) error {
+			var record JoinRecord
+
+			err := record.Decode(encoded)
+			if err != nil {
+				return err
+			}
+
+			records = append(records, record)
+
+			return nil
+		})
+	})
+
+	return records, err
+}
+
+func (store *BoltJoinRecordStore) Close() error {
+	return store.db.Close()
+}
@@ -0,0 +1,210 @@
+package ecdaa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func testRSAEKCert(t *testing.T, serial int64) *x509.Certificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "ek"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return cert
+}
+
+func TestJoinRegistry(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	now := time.Now()
+
+	issuer := RandomIssuer(rng)
+	ek := testRSAEKCert(t, 1)
+	otherEK := testRSAEKCert(t, 2)
+
+	path := filepath.Join(t.TempDir(), "joins.db")
+
+	bolt, err := OpenBoltJoinRecordStore(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	stores := map[string]JoinRecordStore{
+		"memory": NewMemoryJoinRecordStore(),
+		"bolt":   bolt,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			registry := NewJoinRegistry(store, 2)
+
+			join := func(cert *x509.Certificate, verifiers ...EvidenceVerifier) (*JoinRecord, error) {
+				seed, B, err := GenJoinSeed(rng)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+
+				req, _, err := GenJoinReq(seed, rng)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+
+				reqTPM := JoinRequestTPM{
+					JoinReq: req,
+					EKCert:  cert,
+					SrkName: append([]byte{0x00, 0x0b}, amcl_utils.RandomBytes(rng, 32)...),
+				}
+
+				_, _, record, err := registry.MakeCredEncrypted(&issuer, 1, &reqTPM, B, verifiers, now, rng)
+
+				return record, err
+			}
+
+			for i := 0; i < 2; i++ {
+				record, err := join(ek)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+
+				if !record.Accepted || record.ID == 0 || record.Epoch != 1 {
+					t.Fatalf("record is wrong: %v", record)
+				}
+			}
+
+			_, err := join(ek)
+			if err == nil {
+				t.Fatalf("EK joined beyond the limit")
+			}
+
+			_, err = join(otherEK, EvidenceVerifierFunc(func(req *JoinRequestTPM) error {
+				return fmt.Errorf("policy")
+			}))
+			if err == nil {
+				t.Fatalf("join is accepted against the policy")
+			}
+
+			records, err := registry.ByEK(EKFingerprint(ek))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			if len(records) != 3 || records[2].Accepted {
+				t.Fatalf("records of EK are wrong: %v", records)
+			}
+
+			seed, B, err := GenJoinSeed(rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			req, _, err := GenJoinReq(seed, rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			_, record, err := registry.MakeCred(&issuer, 1, req, B, now, rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			got, err := registry.Get(record.ID)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			if !got.Q.Equals(req.Q) || got.EKFingerprint != nil {
+				t.Fatalf("record of join without TPM is wrong: %v", got)
+			}
+
+			revoked, err := registry.RevokeEK(EKFingerprint(ek), now)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			for _, record := range revoked {
+				if !record.Revoked {
+					t.Fatalf("record %v is not revoked", record.ID)
+				}
+			}
+
+			registry = NewJoinRegistry(store, 0)
+
+			_, err = join(ek)
+			if err == nil {
+				t.Fatalf("revoked EK joined")
+			}
+
+			_, err = join(otherEK)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			var buf bytes.Buffer
+
+			err = registry.Export(&buf)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			rows, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			// header, 3 + 1 joins of EK, 2 joins of other EK, 1 join without TPM
+			if len(rows) != 8 {
+				t.Fatalf("exported %v rows", len(rows))
+			}
+		})
+	}
+
+	err = bolt.Close()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	store, err := OpenBoltJoinRecordStore(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	defer store.Close()
+
+	records, err := store.ByEK(EKFingerprint(ek))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if len(records) != 4 || !records[0].Revoked {
+		t.Fatalf("records are not persisted: %v", records)
+	}
+}
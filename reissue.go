@@ -0,0 +1,106 @@
+package ecdaa
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+)
+
+/**
+ * Credential expiry and re-issuance.
+ *
+ * A credential expires with the epoch of the issuer key which issued it
+ * (IssuerEpoch.NotAfter), and VerifyWithIPKSet rejects the signatures
+ * of the expired epochs.
+ *
+ * Before it expires, the member gets a credential of the current epoch
+ * for the same key without the EK-based join: it signs the re-issue message
+ * with the old credential on the basename of a fresh join seed. The pseudonym
+ * K = H(basename)^sk of the signature is Q = B^sk of the seed, so the issuer
+ * makes the new credential on Q knowing the key is of a valid credential.
+ * The TPM key signs it in the TPM, which proves the possession of the key.
+ */
+
+const reissueLabel = "ECDAA-REISSUE"
+
+/**
+ * The message signed with the old credential to re-issue it in the epoch.
+ */
+func ReissueMessage(seed *JoinSeed, epoch uint32) []byte {
+	message := []byte(reissueLabel)
+	message = binary.BigEndian.AppendUint32(message, epoch)
+
+	return append(message, seed.Basename...)
+}
+
+/**
+ * The time the credential of the epoch expires, when the member
+ * should have re-issued it.
+ */
+func (set *IPKSet) Expiry(id uint32) (time.Time, error) {
+	for i := range set.Epochs {
+		if set.Epochs[i].ID == id {
+			return set.Epochs[i].NotAfter, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("epoch %v is not found", id)
+}
+
+/**
+ * Step2'. generate request for re-issue to the epoch with the old credential (by Member)
+ *
+ * signer signs with the old credential, which must be tagged with its epoch
+ * (EpochSigner).
+ */
+func GenReissueReq(seed *JoinSeed, epoch uint32, signer Signer, rng *core.RAND) (*Signature, error) {
+	return signer.Sign(ReissueMessage(seed, epoch), seed.Basename, rng)
+}
+
+/**
+ * Step3'. make credential of the current epoch for the re-issue request (by Issuer)
+ *
+ * The old credential must be valid at the time, or have expired within grace.
+ * B is the point of the seed sent to the member for the request.
+ */
+func (ei *EpochIssuer) Reissue(seed *JoinSeed, B *FP256BN.ECP, req *Signature, now time.Time, grace time.Duration, rl RevocationList, rng *core.RAND) (*Credential, error) {
+	if req.Epoch == ei.Current {
+		return nil, fmt.Errorf("credential is of the current epoch %v", ei.Current)
+	}
+
+	var old *IssuerEpoch
+
+	for i := range ei.Set.Epochs {
+		if ei.Set.Epochs[i].ID == req.Epoch {
+			old = &ei.Set.Epochs[i]
+		}
+	}
+
+	if old == nil {
+		return nil, fmt.Errorf("epoch %v is not found", req.Epoch)
+	}
+
+	if now.Before(old.NotBefore) || !now.Before(old.NotAfter.Add(grace)) {
+		return nil, fmt.Errorf("credential of epoch %v is expired at %v", old.ID, old.NotAfter)
+	}
+
+	err := Verify(ReissueMessage(seed, ei.Current), seed.Basename, req, &old.Ipk, rl)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer, err := ei.CurrentIssuer()
+	if err != nil {
+		return nil, err
+	}
+
+	// K of the signature on the seed basename is B^sk
+	joinReq := JoinRequest{
+		Q: req.Proof.K,
+	}
+
+	return issuer.MakeCred(&joinReq, B, rng)
+}
@@ -0,0 +1,123 @@
+package ecdaa
+
+import (
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestReissue(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	now := time.Now()
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	epochIssuer := NewEpochIssuer()
+
+	first, err := epochIssuer.Rotate(now.Add(-time.Hour), now.Add(time.Hour), rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	firstIssuer, err := epochIssuer.CurrentIssuer()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	sw, err := ExampleJoin(firstIssuer, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	second, err := epochIssuer.Rotate(now, now.Add(2*time.Hour), rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expiry, err := epochIssuer.Set.Expiry(first.ID)
+	if err != nil || !expiry.Equal(first.NotAfter) {
+		t.Fatalf("expiry %v: %v", expiry, err)
+	}
+
+	oldSigner := NewEpochSigner(sw, first.ID)
+
+	seed, B, err := GenJoinSeed(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	req, err := GenReissueReq(seed, second.ID, oldSigner, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := epochIssuer.Reissue(seed, B, req, now.Add(90*time.Minute), 0, RevocationList{}, rng)
+		if err == nil {
+			t.Fatalf("expired credential is re-issued")
+		}
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		_, err := epochIssuer.Reissue(seed, B, req, now, 0, RevocationList{sw.sk}, rng)
+		if err == nil {
+			t.Fatalf("revoked credential is re-issued")
+		}
+	})
+
+	t.Run("other_seed", func(t *testing.T) {
+		otherSeed, otherB, err := GenJoinSeed(rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = epochIssuer.Reissue(otherSeed, otherB, req, now, 0, RevocationList{}, rng)
+		if err == nil {
+			t.Fatalf("request of other seed is accepted")
+		}
+	})
+
+	// re-issue within the grace after the expiry
+	cred, err := epochIssuer.Reissue(seed, B, req, now.Add(90*time.Minute), time.Hour, RevocationList{}, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	err = VerifyCred(cred, &second.Ipk)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	newSigner := NewEpochSigner(NewSWSigner(cred, sw.sk), second.ID)
+
+	signature, err := newSigner.Sign(message, basename, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	oldSignature, err := oldSigner.Sign(message, basename, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	later := now.Add(90 * time.Minute)
+
+	err = VerifyWithIPKSet(message, basename, signature, &epochIssuer.Set, later, RevocationList{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if VerifyWithIPKSet(message, basename, oldSignature, &epochIssuer.Set, later, RevocationList{}) == nil {
+		t.Fatalf("signature of the expired credential is verified")
+	}
+
+	if !signature.Proof.K.Equals(oldSignature.Proof.K) {
+		t.Fatalf("re-issued credential is not for the same key")
+	}
+
+	_, err = epochIssuer.Reissue(seed, B, signature, now, 0, RevocationList{}, rng)
+	if err == nil {
+		t.Fatalf("credential of the current epoch is re-issued")
+	}
+}
@@ -0,0 +1,341 @@
+package ecdaa
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+// domain separation of the signed revocation list
+const revocationListTag = "ecdaa revocation list v1"
+
+/**
+ * Revoked pseudonym: signatures on the basename with the K are rejected.
+ */
+type SignatureRevocation struct {
+	Basename []byte
+	K        *FP256BN.ECP
+}
+
+/**
+ * Issuer-signed revocation list for distribution.
+ *
+ * Sequence starts from 1 and increases with each list the issuer publishes.
+ * A full list has BaseSequence 0 and carries all the entries.
+ * A delta list carries only the entries added since the list of BaseSequence.
+ * Revocations are never lifted, so the entries only grow with the sequence.
+ */
+type SignedRevocationList struct {
+	Sequence     uint64
+	BaseSequence uint64
+	IssuedAt     time.Time
+	NextUpdate   time.Time
+	Keys         RevocationList
+	Signatures   []SignatureRevocation
+	Signature    []byte
+}
+
+func (list *SignedRevocationList) IsDelta() bool {
+	return list.BaseSequence != 0
+}
+
+func appendTime(buf []byte, t time.Time) []byte {
+	buf = binary.BigEndian.AppendUint64(buf, uint64(t.Unix()))
+	return binary.BigEndian.AppendUint32(buf, uint32(t.Nanosecond()))
+}
+
+/**
+ * Canonical bytes of the list without the signature, which are signed:
+ *
+ *	tag || Sequence || BaseSequence || IssuedAt || NextUpdate
+ *	|| len(Keys) || sk ... || len(Signatures) || (len(Basename) || Basename || K) ...
+ *
+ * Integers are big-endian (uint64 sequences, uint32 lengths), times are
+ * the uint64 Unix seconds and the uint32 nanoseconds, sk is 32 bytes and
+ * K is the compressed point.
+ */
+func (list *SignedRevocationList) tbs() ([]byte, error) {
+	buf := []byte(revocationListTag)
+	buf = binary.BigEndian.AppendUint64(buf, list.Sequence)
+	buf = binary.BigEndian.AppendUint64(buf, list.BaseSequence)
+	buf = appendTime(buf, list.IssuedAt)
+	buf = appendTime(buf, list.NextUpdate)
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(list.Keys)))
+
+	for i, key := range list.Keys {
+		if key == nil {
+			return nil, fmt.Errorf("revoked key %v is nil", i)
+		}
+
+		buf = append(buf, amcl_utils.BigToBytes(key)...)
+	}
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(list.Signatures)))
+
+	for i, entry := range list.Signatures {
+		if entry.K == nil {
+			return nil, fmt.Errorf("revoked pseudonym %v is nil", i)
+		}
+
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(entry.Basename)))
+		buf = append(buf, entry.Basename...)
+		buf = append(buf, amcl_utils.EcpToBytes(entry.K)...)
+	}
+
+	return buf, nil
+}
+
+/**
+ * Sign the list with the issuer signing key.
+ */
+func (list *SignedRevocationList) Sign(key ed25519.PrivateKey) error {
+	tbs, err := list.tbs()
+	if err != nil {
+		return err
+	}
+
+	list.Signature = ed25519.Sign(key, tbs)
+
+	return nil
+}
+
+func (list *SignedRevocationList) VerifySignature(pub ed25519.PublicKey) error {
+	tbs, err := list.tbs()
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(pub, tbs, list.Signature) {
+		return fmt.Errorf("revocation list signature is not valid")
+	}
+
+	return nil
+}
+
+func containsKey(keys RevocationList, sk *FP256BN.BIG) bool {
+	for _, key := range keys {
+		if FP256BN.Comp(key, sk) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsSignatureRevocation(entries []SignatureRevocation, entry *SignatureRevocation) bool {
+	for i := range entries {
+		if string(entries[i].Basename) == string(entry.Basename) && entries[i].K.Equals(entry.K) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * Whether the list carries all the entries of the previous list.
+ */
+func (list *SignedRevocationList) includes(previous *SignedRevocationList) bool {
+	for _, key := range previous.Keys {
+		if !containsKey(list.Keys, key) {
+			return false
+		}
+	}
+
+	for i := range previous.Signatures {
+		if !containsSignatureRevocation(list.Signatures, &previous.Signatures[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/**
+ * Delta list of the entries in current which are not in base (unsigned).
+ */
+func NewDeltaRevocationList(base, current *SignedRevocationList) (*SignedRevocationList, error) {
+	if current.Sequence <= base.Sequence {
+		return nil, fmt.Errorf("sequence is not newer: %v <= %v", current.Sequence, base.Sequence)
+	}
+
+	delta := SignedRevocationList{
+		Sequence:     current.Sequence,
+		BaseSequence: base.Sequence,
+		IssuedAt:     current.IssuedAt,
+		NextUpdate:   current.NextUpdate,
+		Keys:         RevocationList{},
+	}
+
+	for _, key := range current.Keys {
+		if !containsKey(base.Keys, key) {
+			delta.Keys = append(delta.Keys, key)
+		}
+	}
+
+	for i := range current.Signatures {
+		if !containsSignatureRevocation(base.Signatures, &current.Signatures[i]) {
+			delta.Signatures = append(delta.Signatures, current.Signatures[i])
+		}
+	}
+
+	return &delta, nil
+}
+
+/**
+ * Full list made by applying the delta to the list (unsigned).
+ */
+func (list *SignedRevocationList) ApplyDelta(delta *SignedRevocationList) (*SignedRevocationList, error) {
+	if delta.BaseSequence != list.Sequence {
+		return nil, fmt.Errorf("delta is based on %v, not %v", delta.BaseSequence, list.Sequence)
+	}
+
+	applied := SignedRevocationList{
+		Sequence:   delta.Sequence,
+		IssuedAt:   delta.IssuedAt,
+		NextUpdate: delta.NextUpdate,
+		Keys:       append(append(RevocationList{}, list.Keys...), delta.Keys...),
+		Signatures: append(append([]SignatureRevocation{}, list.Signatures...), delta.Signatures...),
+	}
+
+	return &applied, nil
+}
+
+/**
+ * Check the signature is not revoked by the entries of the list.
+ */
+func (list *SignedRevocationList) Check(basename []byte, signature *Signature) error {
+	err := checkRevokedKeys(signature, list.Keys, 0)
+	if err != nil {
+		return err
+	}
+
+	if basename == nil || signature.Proof.K == nil {
+		return nil
+	}
+
+	for i := range list.Signatures {
+		entry := &list.Signatures[i]
+
+		if string(entry.Basename) == string(basename) && entry.K.Equals(signature.Proof.K) {
+			return revokedError(ErrPseudonymRevoked, i)
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Verifier-side cache of the revocation list of an issuer.
+ * It accepts only lists signed by the issuer and newer than the cached one,
+ * and refuses to be used once the cached list passes its NextUpdate.
+ */
+type RevocationCache struct {
+	pub  ed25519.PublicKey
+	list *SignedRevocationList
+	mu   sync.RWMutex
+}
+
+func NewRevocationCache(pub ed25519.PublicKey) *RevocationCache {
+	return &RevocationCache{pub: pub}
+}
+
+/**
+ * Update the cache with the full or delta list.
+ */
+func (cache *RevocationCache) Update(list *SignedRevocationList, now time.Time) error {
+	err := list.VerifySignature(cache.pub)
+	if err != nil {
+		return err
+	}
+
+	if now.Before(list.IssuedAt) {
+		return fmt.Errorf("revocation list is issued in the future: %v", list.IssuedAt)
+	}
+
+	if !now.Before(list.NextUpdate) {
+		return fmt.Errorf("revocation list is stale: next update %v", list.NextUpdate)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.list != nil && list.Sequence <= cache.list.Sequence {
+		return fmt.Errorf("revocation list is not newer: %v <= %v", list.Sequence, cache.list.Sequence)
+	}
+
+	if list.IsDelta() {
+		if cache.list == nil {
+			return fmt.Errorf("delta list requires the list %v", list.BaseSequence)
+		}
+
+		list, err = cache.list.ApplyDelta(list)
+		if err != nil {
+			return err
+		}
+	} else if cache.list != nil && !list.includes(cache.list) {
+		return fmt.Errorf("revocation list %v drops entries of %v", list.Sequence, cache.list.Sequence)
+	}
+
+	cache.list = list
+
+	return nil
+}
+
+/**
+ * The cached list, or an error if there is none or it is stale.
+ */
+func (cache *RevocationCache) Current(now time.Time) (*SignedRevocationList, error) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	if cache.list == nil {
+		return nil, fmt.Errorf("revocation list is not cached")
+	}
+
+	if !now.Before(cache.list.NextUpdate) {
+		return nil, fmt.Errorf("revocation list is stale: next update %v", cache.list.NextUpdate)
+	}
+
+	return cache.list, nil
+}
+
+/**
+ * Verify the signature and check it against the cached revocation list.
+ */
+func (cache *RevocationCache) Verify(message, basename []byte, signature *Signature, ipk *IPK, now time.Time) error {
+	list, err := cache.Current(now)
+	if err != nil {
+		return err
+	}
+
+	err = Verify(message, basename, signature, ipk, RevocationList{})
+	if err != nil {
+		return err
+	}
+
+	return list.Check(basename, signature)
+}
+
+/**
+ * Entry revoking the pseudonym of the signature on the basename.
+ */
+func NewSignatureRevocation(basename []byte, signature *Signature) (*SignatureRevocation, error) {
+	if basename == nil || signature.Proof.K == nil {
+		return nil, fmt.Errorf("signature has no pseudonym")
+	}
+
+	K := FP256BN.NewECP()
+	K.Copy(signature.Proof.K)
+
+	return &SignatureRevocation{
+		Basename: basename,
+		K:        K,
+	}, nil
+}
@@ -0,0 +1,213 @@
+package ecdaa
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+// lists shorter than this are checked without spawning workers
+const parallelRevocationThreshold = 64
+
+/**
+ * Index of the pseudonyms of the revoked keys on a basename.
+ *
+ * The Schnorr proof of a linkable signature shows K = B_bsn^sk for the
+ * same sk as the credential, so the signature is revoked iff K is one of
+ * the B_bsn^sk precomputed for the revoked keys.
+ */
+type RevocationIndex struct {
+	basename   []byte
+	pseudonyms map[string]int
+}
+
+/**
+ * Precompute B_bsn^sk of the revoked keys for the basename.
+ */
+func NewRevocationIndex(basename []byte, rl RevocationList) (*RevocationIndex, error) {
+	B, err := basenamePoint(basename)
+	if err != nil {
+		return nil, err
+	}
+
+	index := RevocationIndex{
+		basename:   basename,
+		pseudonyms: make(map[string]int, len(rl)),
+	}
+
+	for i, sk := range rl {
+		K := string(amcl_utils.EcpToBytes(B.Mul(sk)))
+
+		// keep the first entry of the duplicated keys
+		if _, ok := index.pseudonyms[K]; !ok {
+			index.pseudonyms[K] = i
+		}
+	}
+
+	return &index, nil
+}
+
+func (index *RevocationIndex) Revoked(K *FP256BN.ECP) bool {
+	_, ok := index.Entry(K)
+
+	return ok
+}
+
+/**
+ * Index in the revocation list of the key of the pseudonym K.
+ */
+func (index *RevocationIndex) Entry(K *FP256BN.ECP) (int, bool) {
+	entry, ok := index.pseudonyms[string(amcl_utils.EcpToBytes(K))]
+
+	return entry, ok
+}
+
+/**
+ * Check the randomized credential against the revoked keys (D = B^sk),
+ * splitting the list over the workers.
+ */
+func checkRevokedKeys(signature *Signature, rl RevocationList, workers int) error {
+	B := signature.RandomizedCred.B
+	D := signature.RandomizedCred.D
+
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	if len(rl) < parallelRevocationThreshold || workers == 1 {
+		for i, revoked := range rl {
+			if D.Equals(B.Mul(revoked)) {
+				return revokedError(ErrKeyRevoked, i)
+			}
+		}
+
+		return nil
+	}
+
+	// the first revoked entry found, or len(rl)
+	var revoked atomic.Int64
+	var wg sync.WaitGroup
+
+	revoked.Store(int64(len(rl)))
+
+	chunk := (len(rl) + workers - 1) / workers
+
+	for start := 0; start < len(rl); start += chunk {
+		end := start + chunk
+		if len(rl) < end {
+			end = len(rl)
+		}
+
+		wg.Add(1)
+
+		go func(offset int, part RevocationList) {
+			defer wg.Done()
+
+			for i, sk := range part {
+				entry := int64(offset + i)
+				if revoked.Load() < entry {
+					return
+				}
+
+				if D.Equals(B.Mul(sk)) {
+					for {
+						found := revoked.Load()
+						if found < entry || revoked.CompareAndSwap(found, entry) {
+							return
+						}
+					}
+				}
+			}
+		}(start, rl[start:end])
+	}
+
+	wg.Wait()
+
+	entry := int(revoked.Load())
+	if entry < len(rl) {
+		return revokedError(ErrKeyRevoked, entry)
+	}
+
+	return nil
+}
+
+/**
+ * Revocation checker which keeps an index per basename for linkable
+ * signatures, and checks unlinkable signatures with a worker pool.
+ * The indices are built on first use, so it should live as long as the list.
+ */
+type RevocationChecker struct {
+	rl      RevocationList
+	workers int
+	indices map[string]*RevocationIndex
+	mu      sync.Mutex
+}
+
+/**
+ * workers is the size of the pool for unlinkable signatures (NumCPU if 0).
+ */
+func NewRevocationChecker(rl RevocationList, workers int) *RevocationChecker {
+	return &RevocationChecker{
+		rl:      rl,
+		workers: workers,
+		indices: map[string]*RevocationIndex{},
+	}
+}
+
+/**
+ * Build the index of the basename in advance.
+ */
+func (checker *RevocationChecker) Index(basename []byte) (*RevocationIndex, error) {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	index, ok := checker.indices[string(basename)]
+	if ok {
+		return index, nil
+	}
+
+	index, err := NewRevocationIndex(basename, checker.rl)
+	if err != nil {
+		return nil, err
+	}
+
+	checker.indices[string(basename)] = index
+
+	return index, nil
+}
+
+/**
+ * Check the signature, whose proof must already be verified, is not revoked.
+ */
+func (checker *RevocationChecker) Check(basename []byte, signature *Signature) error {
+	if basename == nil || signature.Proof.K == nil {
+		return checkRevokedKeys(signature, checker.rl, checker.workers)
+	}
+
+	index, err := checker.Index(basename)
+	if err != nil {
+		return err
+	}
+
+	entry, ok := index.Entry(signature.Proof.K)
+	if ok {
+		return revokedError(ErrKeyRevoked, entry)
+	}
+
+	return nil
+}
+
+/**
+ * Verify the signature and check the revocation with the checker.
+ */
+func VerifyWithChecker(message, basename []byte, signature *Signature, ipk *IPK, checker *RevocationChecker) error {
+	err := Verify(message, basename, signature, ipk, RevocationList{})
+	if err != nil {
+		return err
+	}
+
+	return checker.Check(basename, signature)
+}
@@ -0,0 +1,104 @@
+package ecdaa
+
+import (
+	"fmt"
+	"testing"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestRevocationChecker(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	issuer, revokedSigner, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	honestSigner, err := ExampleJoin(issuer, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	rl := RevocationList{}
+	for i := 0; i < 2*parallelRevocationThreshold; i++ {
+		rl = append(rl, amcl_utils.RandomBig(rng))
+	}
+	rl[parallelRevocationThreshold+1] = revokedSigner.sk
+
+	checker := NewRevocationChecker(rl, 4)
+
+	for _, bsn := range [][]byte{basename, nil} {
+		name := "linkable"
+		if bsn == nil {
+			name = "unlinkable"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			revoked, err := revokedSigner.Sign(message, bsn, rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			honest, err := honestSigner.Sign(message, bsn, rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			if VerifyWithChecker(message, bsn, revoked, &issuer.Ipk, checker) == nil {
+				t.Fatalf("revoked signature is verified")
+			}
+
+			if Verify(message, bsn, revoked, &issuer.Ipk, rl) == nil {
+				t.Fatalf("revoked signature is verified by Verify")
+			}
+
+			err = VerifyWithChecker(message, bsn, honest, &issuer.Ipk, checker)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+		})
+	}
+}
+
+func BenchmarkVerifyWithChecker(b *testing.B) {
+	rng := amcl_utils.InitRandom()
+	basename := []byte("basename")
+
+	issuer, signer, err := ExampleInitialize(rng)
+	if err != nil {
+		b.Fatalf("%v", err)
+	}
+
+	for count := 1; count <= 100000; count *= 10 {
+		rl := RevocationList{}
+		for i := 0; i < count; i++ {
+			rl = append(rl, amcl_utils.RandomBig(rng))
+		}
+
+		checker := NewRevocationChecker(rl, 0)
+
+		_, err = checker.Index(basename)
+		if err != nil {
+			b.Fatalf("%v", err)
+		}
+
+		for _, bsn := range [][]byte{basename, nil} {
+			signature, err := signer.Sign([]byte{}, bsn, rng)
+			if err != nil {
+				b.Fatalf("%v", err)
+			}
+
+			b.Run(fmt.Sprintf("verify_checker_%v_linkable_%v", count, bsn != nil), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					err := VerifyWithChecker([]byte{}, bsn, signature, &issuer.Ipk, checker)
+					if err != nil {
+						b.Fatalf("%v", err)
+					}
+				}
+			})
+		}
+	}
+}
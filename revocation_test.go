@@ -0,0 +1,196 @@
+package ecdaa
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestSignedRevocationList(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	now := time.Now()
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	issuer, revokedSigner, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	pseudonymSigner, err := ExampleJoin(issuer, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	honestSigner, err := ExampleJoin(issuer, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	sign := func(signer *SWSigner) *Signature {
+		signature, err := signer.Sign(message, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		return signature
+	}
+
+	revokedSignature := sign(revokedSigner)
+	pseudonymSignature := sign(pseudonymSigner)
+	honestSignature := sign(honestSigner)
+
+	base := SignedRevocationList{
+		Sequence:   1,
+		IssuedAt:   now.Add(-time.Minute),
+		NextUpdate: now.Add(time.Hour),
+		Keys:       RevocationList{revokedSigner.sk},
+	}
+
+	entry, err := NewSignatureRevocation(basename, pseudonymSignature)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	current := base
+	current.Sequence = 2
+	current.Signatures = []SignatureRevocation{*entry}
+
+	delta, err := NewDeltaRevocationList(&base, &current)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	for _, list := range []*SignedRevocationList{&base, &current, delta} {
+		err = list.Sign(priv)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	t.Run("encode", func(t *testing.T) {
+		encoded, err := delta.Encode()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var decoded SignedRevocationList
+
+		err = decoded.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = decoded.VerifySignature(pub)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		decoded.Sequence = 3
+
+		if decoded.VerifySignature(pub) == nil {
+			t.Fatalf("modified list is verified")
+		}
+	})
+
+	t.Run("cache_with_delta", func(t *testing.T) {
+		cache := NewRevocationCache(pub)
+
+		if cache.Update(delta, now) == nil {
+			t.Fatalf("delta is accepted without base")
+		}
+
+		err := cache.Update(&base, now)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if cache.Verify(message, basename, revokedSignature, &issuer.Ipk, now) == nil {
+			t.Fatalf("revoked key is verified")
+		}
+
+		err = cache.Verify(message, basename, pseudonymSignature, &issuer.Ipk, now)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = cache.Update(delta, now)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if cache.Verify(message, basename, pseudonymSignature, &issuer.Ipk, now) == nil {
+			t.Fatalf("revoked pseudonym is verified")
+		}
+
+		err = cache.Verify(message, basename, honestSignature, &issuer.Ipk, now)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if cache.Update(&base, now) == nil {
+			t.Fatalf("older list is accepted")
+		}
+	})
+
+	t.Run("dropped_entry", func(t *testing.T) {
+		cache := NewRevocationCache(pub)
+
+		err := cache.Update(&current, now)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		dropped := current
+		dropped.Sequence = 3
+		dropped.Keys = RevocationList{}
+
+		err = dropped.Sign(priv)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if cache.Update(&dropped, now) == nil {
+			t.Fatalf("list dropping the revoked key is accepted")
+		}
+
+		if cache.Verify(message, basename, revokedSignature, &issuer.Ipk, now) == nil {
+			t.Fatalf("revoked key is verified")
+		}
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		cache := NewRevocationCache(pub)
+
+		err := cache.Update(&current, now)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		later := now.Add(2 * time.Hour)
+
+		if cache.Verify(message, basename, honestSignature, &issuer.Ipk, later) == nil {
+			t.Fatalf("verified with stale list")
+		}
+
+		if NewRevocationCache(pub).Update(&current, later) == nil {
+			t.Fatalf("stale list is accepted")
+		}
+	})
+
+	t.Run("wrong_key", func(t *testing.T) {
+		otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+		if NewRevocationCache(otherPub).Update(&base, now) == nil {
+			t.Fatalf("list signed by other key is accepted")
+		}
+	})
+}
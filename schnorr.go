@@ -1,8 +1,6 @@
 package ecdaa
 
 import (
-	"fmt"
-
 	"github.com/akakou-fork/amcl-go/miracl/core"
 
 	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
@@ -52,10 +50,18 @@ func proveSchnorr(message, basename []byte, sk *FP256BN.BIG, S, W *FP256BN.ECP,
 	hash.WriteBytes(basename)
 	B, _, _ := hash.HashToECP()
 
-	r, E, L, K := commit(sk, B, S, rng, true)
+	// K is the pseudonym on the basename, so the unlinkable proofs have none
+	r, E, L, K := commit(sk, B, S, rng, basename != nil)
+
+	return respondSchnorr(message, basename, sk, S, W, B, r, E, L, K, rng)
+}
 
+/**
+ * The online phase of the proof with the commitment (r, E, L, K) on B.
+ */
+func respondSchnorr(message, basename []byte, sk *FP256BN.BIG, S, W, B *FP256BN.ECP, r *FP256BN.BIG, E, L, K *FP256BN.ECP, rng *core.RAND) *SchnorrProof {
 	// c' = H(E, S, W, L, B, K, basename, message)
-	hash = amcl_utils.NewHash()
+	hash := amcl_utils.NewHash()
 	if basename == nil {
 		hash.WriteECP(E, S, W)
 	} else {
@@ -77,6 +83,10 @@ func proveSchnorr(message, basename []byte, sk *FP256BN.BIG, S, W *FP256BN.ECP,
 }
 
 func verifySchnorr(message, basename []byte, proof *SchnorrProof, S, W *FP256BN.ECP) error {
+	if basename != nil && !validPoint(proof.K) {
+		return verifyError(ErrMalformed, "proof has no K for the basename")
+	}
+
 	// E = S^s W ^ (-c)
 	E := S.Mul(proof.SmallS)
 	tmp := W.Mul(proof.SmallC)
@@ -117,7 +127,7 @@ func verifySchnorr(message, basename []byte, proof *SchnorrProof, S, W *FP256BN.
 	c := hash.SumToBIG()
 
 	if FP256BN.Comp(proof.SmallC, c) != 0 {
-		return fmt.Errorf("c is not match: %v != %v", proof.SmallC, c)
+		return verifyError(ErrProofInvalid, "c is not match: %v != %v", proof.SmallC, c)
 	}
 
 	return nil
@@ -0,0 +1,98 @@
+package ecdaa
+
+import (
+	"fmt"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+/**
+ * Signer whose software secret key is sealed to the TPM.
+ *
+ * The sk is kept as a sealed data object under the SRK (optionally bound
+ * to PCRs) and unsealed only while signing, for TPMs which lack
+ * the support of ECDAA with BN_P256.
+ */
+type SealedSigner struct {
+	cred *Credential
+	blob *tpm_utils.SealedBlob
+	tpm  *tpm_utils.TPM
+}
+
+func NewSealedSigner(cred *Credential, blob *tpm_utils.SealedBlob, tpm *tpm_utils.TPM) SealedSigner {
+	var signer = SealedSigner{
+		cred: cred,
+		blob: blob,
+		tpm:  tpm,
+	}
+
+	return signer
+}
+
+/**
+ * Step2. generate request for join with the secret key sealed to the TPM (by Member)
+ */
+func GenJoinReqSealed(seed *JoinSeed, tpm *tpm_utils.TPM, pcrs []uint, rng *core.RAND) (*JoinRequest, *tpm_utils.SealedBlob, error) {
+	req, sk, err := GenJoinReq(seed, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer zeroizeBIG(sk)
+
+	skBuf := amcl_utils.BigToBytes(sk)
+	defer zeroize(skBuf)
+
+	blob, err := tpm.Seal(skBuf, pcrs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("seal: %v", err)
+	}
+
+	return req, blob, nil
+}
+
+/**
+ * Seal the secret key of the software signer to the TPM.
+ */
+func SealSWSigner(signer *SWSigner, tpm *tpm_utils.TPM, pcrs []uint) (*SealedSigner, error) {
+	skBuf := amcl_utils.BigToBytes(signer.sk)
+	defer zeroize(skBuf)
+
+	blob, err := tpm.Seal(skBuf, pcrs)
+	if err != nil {
+		return nil, fmt.Errorf("seal: %v", err)
+	}
+
+	sealed := NewSealedSigner(signer.cred, blob, tpm)
+
+	return &sealed, nil
+}
+
+/**
+ * Set the TPM which unseals the key, e.g. after Decode.
+ */
+func (signer *SealedSigner) SetTPM(tpm *tpm_utils.TPM) {
+	signer.tpm = tpm
+}
+
+func (signer SealedSigner) Sign(message, basename []byte, rng *core.RAND) (*Signature, error) {
+	if signer.tpm == nil {
+		return nil, fmt.Errorf("TPM of the sealed signer is not set")
+	}
+
+	skBuf, err := signer.tpm.Unseal(signer.blob)
+	if err != nil {
+		return nil, fmt.Errorf("unseal: %v", err)
+	}
+	defer zeroize(skBuf)
+
+	sk := FP256BN.FromBytes(skBuf)
+	defer zeroizeBIG(sk)
+
+	sw := NewSWSigner(signer.cred, sk)
+
+	return sw.Sign(message, basename, rng)
+}
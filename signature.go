@@ -24,6 +24,8 @@ type KeyHandles struct {
 	EkHandle  *tpm2.AuthHandle
 	SrkHandle *tpm2.NamedHandle
 	Handle    *tpm2.AuthHandle
+	// AK the credential is bound to instead of the SRK (GenJoinReqWithQuote)
+	AkHandle *tpm2.NamedHandle
 }
 
 func NewMember(tpm *tpm_utils.TPM) Member {
@@ -37,6 +39,8 @@ func NewMember(tpm *tpm_utils.TPM) Member {
 type Signature struct {
 	Proof          *SchnorrProof
 	RandomizedCred *Credential
+	Epoch          uint32
+	Fingerprint    []byte
 }
 
 type SWSigner struct {
@@ -87,20 +91,36 @@ func (signer SWSigner) Sign(
 	}, nil
 }
 
-func (signer *TPMSigner) Sign(message, basename []byte, rng *core.RAND) (*Signature, error) {
+// B = H(basename) and the s2 given to the TPM to derive it
+func basenameCommit(basename []byte) (*FP256BN.ECP, []byte, error) {
 	hash := amcl_utils.NewHash()
 	hash.WriteBytes(basename)
 
 	B, i, err := hash.HashToECP()
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	numBuf := make([]byte, binary.MaxVarintLen32)
 	binary.PutVarint(numBuf, int64(i))
 
-	s2Buf := append(numBuf, basename[:]...)
+	return B, append(numBuf, basename[:]...), nil
+}
+
+func (signer *TPMSigner) Sign(message, basename []byte, rng *core.RAND) (*Signature, error) {
+	var B *FP256BN.ECP
+	var s2Buf []byte
+
+	// only the signatures on a basename commit to B and have the pseudonym K
+	if basename != nil {
+		var err error
+
+		B, s2Buf, err = basenameCommit(basename)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	randomizedCred := RandomizeCred(signer.cred, rng)
 	S := randomizedCred.B
@@ -113,9 +133,15 @@ func (signer *TPMSigner) Sign(message, basename []byte, rng *core.RAND) (*Signat
 		return nil, fmt.Errorf("commit error: %v", err)
 	}
 
-	// c2 = H(E, S, W, L, B, K,basename, message)
-	hash = amcl_utils.NewHash()
-	hash.WriteECP(E, S, W, L, B, K)
+	// c2 = H(E, S, W, L, B, K,basename, message), or H(E, S, W, message) without basename
+	hash := amcl_utils.NewHash()
+
+	if basename == nil {
+		hash.WriteECP(E, S, W)
+	} else {
+		hash.WriteECP(E, S, W, L, B, K)
+	}
+
 	hash.WriteBytes(basename, message)
 
 	c2 := hash.SumToBIG()
@@ -151,26 +177,21 @@ func (signer *TPMSigner) Sign(message, basename []byte, rng *core.RAND) (*Signat
 }
 
 func Verify(message, basename []byte, signature *Signature, ipk *IPK, rl RevocationList) error {
-	err := verifySchnorr(message, basename, signature.Proof, signature.RandomizedCred.B, signature.RandomizedCred.D)
-
+	err := checkSignature(signature)
 	if err != nil {
 		return err
 	}
 
-	err = VerifyCred(signature.RandomizedCred, ipk)
+	err = verifySchnorr(message, basename, signature.Proof, signature.RandomizedCred.B, signature.RandomizedCred.D)
+
 	if err != nil {
 		return err
 	}
 
-	for _, revoked := range rl {
-		tmp4 := FP256BN.NewECP()
-		tmp4.Copy(signature.RandomizedCred.B)
-		tmp4 = tmp4.Mul(revoked)
-
-		if signature.RandomizedCred.D.Equals(tmp4) {
-			return fmt.Errorf("the secret key revoked")
-		}
+	err = VerifyCred(signature.RandomizedCred, ipk)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return checkRevokedKeys(signature, rl, 0)
 }
@@ -20,46 +20,55 @@ func ExampleInitialize(rng *core.RAND) (*Issuer, *SWSigner, error) {
 		return nil, nil, err
 	}
 
+	signer, err := ExampleJoin(issuer, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return issuer, signer, nil
+}
+
+func ExampleJoin(issuer *Issuer, rng *core.RAND) (*SWSigner, error) {
 	seed, issuerB, err := GenJoinSeed(rng)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	req, sk, err := GenJoinReq(seed, rng)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	err = VerifyJoinReq(req, seed, issuerB)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	cred, err := issuer.MakeCred(req, issuerB, rng)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	err = VerifyCred(cred, &issuer.Ipk)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	randCred := RandomizeCred(cred, rng)
 	err = VerifyCred(randCred, &issuer.Ipk)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	signer := NewSWSigner(cred, sk)
 
-	return issuer, &signer, nil
+	return &signer, nil
 }
 
 func ExampleTPMInitialize(tpm *tpm_utils.TPM, rng *core.RAND) (*Issuer, *TPMSigner, error) {
@@ -94,3 +103,34 @@ func ExampleTPMInitialize(tpm *tpm_utils.TPM, rng *core.RAND) (*Issuer, *TPMSign
 
 	return issuer, &signer, nil
 }
+
+func ExampleSealedInitialize(tpm *tpm_utils.TPM, rng *core.RAND) (*Issuer, *SealedSigner, error) {
+	issuer, err := testIssuer(rng)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seed, issuerB, err := GenJoinSeed(rng)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, blob, err := GenJoinReqSealed(seed, tpm, []uint{}, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = VerifyJoinReq(req, seed, issuerB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cred, err := issuer.MakeCred(req, issuerB, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer := NewSealedSigner(cred, blob, tpm)
+
+	return issuer, &signer, nil
+}
@@ -0,0 +1,579 @@
+package ecdaa
+
+import (
+	"fmt"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * Threshold (t-of-n) distributed issuer.
+ *
+ * The ISK (x, y) is Shamir-shared among n issuer nodes by a distributed key
+ * generation (joint Feldman VSS over G2), so that no single node knows it.
+ * Any t nodes can issue a credential from their partial credentials.
+ */
+
+/**
+ * Deal of a node in the distributed key generation.
+ * CommitX/CommitY are the Feldman commitments g2^a_k of the polynomials,
+ * and ShareX/ShareY are the shares for each node (sent over private channels).
+ */
+type DKGDeal struct {
+	Dealer  int
+	CommitX []*FP256BN.ECP2
+	CommitY []*FP256BN.ECP2
+	ShareX  map[int]*FP256BN.BIG
+	ShareY  map[int]*FP256BN.BIG
+}
+
+/**
+ * Shares of a node for the pair of shared secrets (x, y),
+ * with the commitments of the joint polynomials.
+ */
+type SharedPair struct {
+	X       *FP256BN.BIG
+	Y       *FP256BN.BIG
+	CommitX []*FP256BN.ECP2
+	CommitY []*FP256BN.ECP2
+}
+
+type IssuerNode struct {
+	ID  int
+	T   int
+	N   int
+	Key *SharedPair
+}
+
+/**
+ * Public information of the distributed issuer.
+ */
+type ThresholdGroup struct {
+	T   int
+	N   int
+	Ipk IPK
+	Key *SharedPair
+}
+
+type PartialCredential struct {
+	ID int
+	B  *FP256BN.ECP
+	C  *FP256BN.ECP
+	D  *FP256BN.ECP
+}
+
+/**
+ * State of a credential issuance run by the coordinator.
+ *
+ * A = B_join^l with a random l chosen by the coordinator, because
+ * B_join^(1/y) cannot be computed linearly from the shares of y.
+ * Then B = A^y, D = Q^(l*y) and C = (A*D)^x are computed from partial credentials.
+ *
+ * The seed and the join request are carried so that each node checks
+ * them by itself, and does not trust the coordinator.
+ */
+type CredentialSession struct {
+	Seed *JoinSeed
+	Req  *JoinRequest
+	A    *FP256BN.ECP
+	B    *FP256BN.ECP
+	D    *FP256BN.ECP
+	L    *FP256BN.BIG
+}
+
+func NewIssuerNode(id, t, n int) (*IssuerNode, error) {
+	if t < 1 || t > n || id < 1 || id > n {
+		return nil, fmt.Errorf("invalid threshold parameters: id=%v, t=%v, n=%v", id, t, n)
+	}
+
+	node := IssuerNode{
+		ID: id,
+		T:  t,
+		N:  n,
+	}
+
+	return &node, nil
+}
+
+// f(i) = Σ coefficients_k * i^k
+func evalPolynomial(coefficients []*FP256BN.BIG, i int) *FP256BN.BIG {
+	result := FP256BN.NewBIGint(0)
+	pow := FP256BN.NewBIGint(1)
+	x := FP256BN.NewBIGint(i)
+
+	for _, a := range coefficients {
+		result = FP256BN.Modadd(result, FP256BN.Modmul(a, pow, amcl_utils.P()), amcl_utils.P())
+		pow = FP256BN.Modmul(pow, x, amcl_utils.P())
+	}
+
+	return result
+}
+
+// Π commitments_k^(i^k)
+func evalCommitment(commitments []*FP256BN.ECP2, i int) *FP256BN.ECP2 {
+	result := FP256BN.NewECP2()
+	pow := FP256BN.NewBIGint(1)
+	x := FP256BN.NewBIGint(i)
+
+	for _, commitment := range commitments {
+		result.Add(commitment.Mul(pow))
+		pow = FP256BN.Modmul(pow, x, amcl_utils.P())
+	}
+
+	return result
+}
+
+func dealPolynomial(t, n int, rng *core.RAND) ([]*FP256BN.ECP2, map[int]*FP256BN.BIG) {
+	var coefficients []*FP256BN.BIG
+	var commitments []*FP256BN.ECP2
+
+	for k := 0; k < t; k++ {
+		a := amcl_utils.RandomBig(rng)
+		a.Mod(amcl_utils.P())
+
+		coefficients = append(coefficients, a)
+		commitments = append(commitments, amcl_utils.G2().Mul(a))
+	}
+
+	shares := map[int]*FP256BN.BIG{}
+
+	for j := 1; j <= n; j++ {
+		shares[j] = evalPolynomial(coefficients, j)
+	}
+
+	return commitments, shares
+}
+
+/**
+ * Lagrange coefficient at 0 of the node i among the nodes ids.
+ */
+func lagrange(i int, ids []int) *FP256BN.BIG {
+	num := FP256BN.NewBIGint(1)
+	den := FP256BN.NewBIGint(1)
+
+	for _, j := range ids {
+		if j == i {
+			continue
+		}
+
+		num = FP256BN.Modmul(num, FP256BN.NewBIGint(j), amcl_utils.P())
+		den = FP256BN.Modmul(den, modSub(FP256BN.NewBIGint(j), FP256BN.NewBIGint(i)), amcl_utils.P())
+	}
+
+	den.Invmodp(amcl_utils.P())
+
+	return FP256BN.Modmul(num, den, amcl_utils.P())
+}
+
+/**
+ * Deal random polynomials of degree t-1 for x and y.
+ */
+func (node *IssuerNode) Deal(rng *core.RAND) *DKGDeal {
+	var deal DKGDeal
+
+	deal.Dealer = node.ID
+	deal.CommitX, deal.ShareX = dealPolynomial(node.T, node.N, rng)
+	deal.CommitY, deal.ShareY = dealPolynomial(node.T, node.N, rng)
+
+	return &deal
+}
+
+/**
+ * Verify the shares for this node in the deals and combine them.
+ */
+func (node *IssuerNode) Combine(deals []*DKGDeal) (*SharedPair, error) {
+	if len(deals) != node.N {
+		return nil, fmt.Errorf("deals are not enough: %v != %v", len(deals), node.N)
+	}
+
+	pair := SharedPair{
+		X: FP256BN.NewBIGint(0),
+		Y: FP256BN.NewBIGint(0),
+	}
+
+	for k := 0; k < node.T; k++ {
+		pair.CommitX = append(pair.CommitX, FP256BN.NewECP2())
+		pair.CommitY = append(pair.CommitY, FP256BN.NewECP2())
+	}
+
+	for _, deal := range deals {
+		if len(deal.CommitX) != node.T || len(deal.CommitY) != node.T {
+			return nil, fmt.Errorf("deal of %v is malformed", deal.Dealer)
+		}
+
+		x, okX := deal.ShareX[node.ID]
+		y, okY := deal.ShareY[node.ID]
+
+		if !okX || !okY {
+			return nil, fmt.Errorf("share from %v is missing", deal.Dealer)
+		}
+
+		if !amcl_utils.G2().Mul(x).Equals(evalCommitment(deal.CommitX, node.ID)) ||
+			!amcl_utils.G2().Mul(y).Equals(evalCommitment(deal.CommitY, node.ID)) {
+			return nil, fmt.Errorf("share from %v is not valid", deal.Dealer)
+		}
+
+		pair.X = FP256BN.Modadd(pair.X, x, amcl_utils.P())
+		pair.Y = FP256BN.Modadd(pair.Y, y, amcl_utils.P())
+
+		for k := 0; k < node.T; k++ {
+			pair.CommitX[k].Add(deal.CommitX[k])
+			pair.CommitY[k].Add(deal.CommitY[k])
+		}
+	}
+
+	return &pair, nil
+}
+
+/**
+ * Public keys of the shares of the node: (g2^x_i, g2^y_i).
+ */
+func (pair *SharedPair) PublicShares(id int) (*FP256BN.ECP2, *FP256BN.ECP2) {
+	return evalCommitment(pair.CommitX, id), evalCommitment(pair.CommitY, id)
+}
+
+/**
+ * Partial responses of the IPK proof, s_i = r_i + c * x_i.
+ */
+func (node *IssuerNode) PartialIPKProof(nonce *SharedPair, c *FP256BN.BIG) (*FP256BN.BIG, *FP256BN.BIG) {
+	return schnorrResponse(nonce.X, c, node.Key.X), schnorrResponse(nonce.Y, c, node.Key.Y)
+}
+
+/**
+ * Run the distributed key generation with in-process nodes,
+ * and compute the IPK proof jointly by the first t nodes.
+ */
+func ThresholdKeyGen(t, n int, rng *core.RAND) ([]*IssuerNode, *ThresholdGroup, error) {
+	var nodes []*IssuerNode
+
+	for id := 1; id <= n; id++ {
+		node, err := NewIssuerNode(id, t, n)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	runDKG := func() ([]*SharedPair, error) {
+		var deals []*DKGDeal
+		var pairs []*SharedPair
+
+		for _, node := range nodes {
+			deals = append(deals, node.Deal(rng))
+		}
+
+		for _, node := range nodes {
+			pair, err := node.Combine(deals)
+			if err != nil {
+				return nil, err
+			}
+
+			pairs = append(pairs, pair)
+		}
+
+		return pairs, nil
+	}
+
+	keys, err := runDKG()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, node := range nodes {
+		node.Key = keys[i]
+	}
+
+	nonces, err := runDKG()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	X := keys[0].CommitX[0]
+	Y := keys[0].CommitY[0]
+	Ux := nonces[0].CommitX[0]
+	Uy := nonces[0].CommitY[0]
+
+	// c = H(U_x | U_y | g2 | X | Y)
+	hash := amcl_utils.NewHash()
+	hash.WriteECP2(Ux, Uy, amcl_utils.G2(), X, Y)
+	c := hash.SumToBIG()
+
+	var ids []int
+	for _, node := range nodes[:t] {
+		ids = append(ids, node.ID)
+	}
+
+	sX := FP256BN.NewBIGint(0)
+	sY := FP256BN.NewBIGint(0)
+
+	for i, node := range nodes[:t] {
+		partialX, partialY := node.PartialIPKProof(nonces[i], c)
+
+		// g2^s_i = U_i * X_i^c
+		pubX, pubY := keys[0].PublicShares(node.ID)
+		nonceX, nonceY := nonces[0].PublicShares(node.ID)
+
+		expectedX := pubX.Mul(c)
+		expectedX.Add(nonceX)
+
+		expectedY := pubY.Mul(c)
+		expectedY.Add(nonceY)
+
+		if !amcl_utils.G2().Mul(partialX).Equals(expectedX) || !amcl_utils.G2().Mul(partialY).Equals(expectedY) {
+			return nil, nil, fmt.Errorf("partial IPK proof of %v is not valid", node.ID)
+		}
+
+		lambda := lagrange(node.ID, ids)
+
+		sX = FP256BN.Modadd(sX, FP256BN.Modmul(lambda, partialX, amcl_utils.P()), amcl_utils.P())
+		sY = FP256BN.Modadd(sY, FP256BN.Modmul(lambda, partialY, amcl_utils.P()), amcl_utils.P())
+	}
+
+	group := ThresholdGroup{
+		T: t,
+		N: n,
+		Ipk: IPK{
+			X:  X,
+			Y:  Y,
+			C:  c,
+			SX: sX,
+			SY: sY,
+		},
+		Key: &SharedPair{
+			CommitX: keys[0].CommitX,
+			CommitY: keys[0].CommitY,
+		},
+	}
+
+	err = VerifyIPK(&group.Ipk)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return nodes, &group, nil
+}
+
+/**
+ * Start the credential issuance for the join request on the seed (by Coordinator).
+ */
+func NewCredentialSession(seed *JoinSeed, req *JoinRequest, B *FP256BN.ECP, rng *core.RAND) *CredentialSession {
+	l := nonZeroRandom(rng)
+
+	session := CredentialSession{
+		Seed: seed,
+		Req:  req,
+		A:    B.Mul(l),
+		L:    l,
+	}
+
+	return &session
+}
+
+/**
+ * Check the join request is proven on the seed, and A is derived from it.
+ */
+func (node *IssuerNode) checkSession(session *CredentialSession) error {
+	if session.Req == nil || session.Req.Proof == nil || !validPoint(session.Req.Q) {
+		return fmt.Errorf("join request is incomplete")
+	}
+
+	if session.L == nil || FP256BN.Comp(session.L, FP256BN.NewBIGint(0)) == 0 || session.A == nil {
+		return fmt.Errorf("session is incomplete")
+	}
+
+	B, err := seedPoint(session.Seed)
+	if err != nil {
+		return err
+	}
+
+	err = VerifyJoinReq(session.Req, session.Seed, B)
+	if err != nil {
+		return err
+	}
+
+	if !session.A.Equals(B.Mul(session.L)) {
+		return fmt.Errorf("A is not derived from the join seed")
+	}
+
+	return nil
+}
+
+/**
+ * Round 1: B_i = A^y_i, D_i = Q^(l * y_i) (by Issuer node)
+ */
+func (node *IssuerNode) PartialCredBD(session *CredentialSession) (*PartialCredential, error) {
+	err := node.checkSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	ly := FP256BN.Modmul(session.L, node.Key.Y, amcl_utils.P())
+
+	return &PartialCredential{
+		ID: node.ID,
+		B:  session.A.Mul(node.Key.Y),
+		D:  session.Req.Q.Mul(ly),
+	}, nil
+}
+
+/**
+ * Round 2: C_i = (A * D)^x_i (by Issuer node)
+ *
+ * B and D combined by the coordinator are checked against Y = g2^y,
+ * so that the node signs only A * D of the session.
+ */
+func (node *IssuerNode) PartialCredC(session *CredentialSession) (*PartialCredential, error) {
+	if session.B == nil || session.D == nil {
+		return nil, fmt.Errorf("B and D are not combined yet")
+	}
+
+	err := node.checkSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	Y := node.Key.CommitY[0]
+
+	// e(Y, A) = e(g2, B), e(Y, Q^l) = e(g2, D)
+	if !pairingEquals(Y, session.A, amcl_utils.G2(), session.B) ||
+		!pairingEquals(Y, session.Req.Q.Mul(session.L), amcl_utils.G2(), session.D) {
+		return nil, fmt.Errorf("B and D are not of the session")
+	}
+
+	AD := FP256BN.NewECP()
+	AD.Copy(session.A)
+	AD.Add(session.D)
+
+	return &PartialCredential{
+		ID: node.ID,
+		C:  AD.Mul(node.Key.X),
+	}, nil
+}
+
+func partialIDs(partials []*PartialCredential, t int) ([]int, error) {
+	if len(partials) < t {
+		return nil, fmt.Errorf("partial credentials are not enough: %v < %v", len(partials), t)
+	}
+
+	var ids []int
+	seen := map[int]bool{}
+
+	for _, partial := range partials {
+		if seen[partial.ID] {
+			return nil, fmt.Errorf("duplicated partial credential from %v", partial.ID)
+		}
+
+		seen[partial.ID] = true
+		ids = append(ids, partial.ID)
+	}
+
+	return ids, nil
+}
+
+/**
+ * Combine B and D from the partial credentials of round 1 (by Coordinator).
+ */
+func (session *CredentialSession) CombineBD(partials []*PartialCredential, group *ThresholdGroup) error {
+	ids, err := partialIDs(partials, group.T)
+	if err != nil {
+		return err
+	}
+
+	B := FP256BN.NewECP()
+	D := FP256BN.NewECP()
+
+	for _, partial := range partials {
+		_, Yi := group.Key.PublicShares(partial.ID)
+
+		// e(Y_i, A) = e(g2, B_i), e(Y_i, Q^l) = e(g2, D_i)
+		if !pairingEquals(Yi, session.A, amcl_utils.G2(), partial.B) ||
+			!pairingEquals(Yi, session.Req.Q.Mul(session.L), amcl_utils.G2(), partial.D) {
+			return fmt.Errorf("partial credential of %v is not valid", partial.ID)
+		}
+
+		lambda := lagrange(partial.ID, ids)
+
+		B.Add(partial.B.Mul(lambda))
+		D.Add(partial.D.Mul(lambda))
+	}
+
+	session.B = B
+	session.D = D
+
+	return nil
+}
+
+/**
+ * Combine C from the partial credentials of round 2 (by Coordinator).
+ */
+func (session *CredentialSession) CombineC(partials []*PartialCredential, group *ThresholdGroup) (*Credential, error) {
+	ids, err := partialIDs(partials, group.T)
+	if err != nil {
+		return nil, err
+	}
+
+	AD := FP256BN.NewECP()
+	AD.Copy(session.A)
+	AD.Add(session.D)
+
+	C := FP256BN.NewECP()
+
+	for _, partial := range partials {
+		Xi, _ := group.Key.PublicShares(partial.ID)
+
+		// e(X_i, A * D) = e(g2, C_i)
+		if !pairingEquals(Xi, AD, amcl_utils.G2(), partial.C) {
+			return nil, fmt.Errorf("partial credential of %v is not valid", partial.ID)
+		}
+
+		C.Add(partial.C.Mul(lagrange(partial.ID, ids)))
+	}
+
+	cred := Credential{
+		A: session.A,
+		B: session.B,
+		C: C,
+		D: session.D,
+	}
+
+	return &cred, nil
+}
+
+/**
+ * Step3. make credential for join by the given issuer nodes (at least t of them).
+ */
+func ThresholdMakeCred(nodes []*IssuerNode, group *ThresholdGroup, seed *JoinSeed, req *JoinRequest, B *FP256BN.ECP, rng *core.RAND) (*Credential, error) {
+	session := NewCredentialSession(seed, req, B, rng)
+
+	var partials []*PartialCredential
+
+	for _, node := range nodes {
+		partial, err := node.PartialCredBD(session)
+		if err != nil {
+			return nil, err
+		}
+
+		partials = append(partials, partial)
+	}
+
+	err := session.CombineBD(partials, group)
+	if err != nil {
+		return nil, err
+	}
+
+	partials = nil
+
+	for _, node := range nodes {
+		partial, err := node.PartialCredC(session)
+		if err != nil {
+			return nil, err
+		}
+
+		partials = append(partials, partial)
+	}
+
+	return session.CombineC(partials, group)
+}
@@ -0,0 +1,136 @@
+package ecdaa
+
+import (
+	"testing"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestThresholdIssuer(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+
+	nodes, group, err := ThresholdKeyGen(2, 3, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	seed, issuerB, err := GenJoinSeed(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	req, sk, err := GenJoinReq(seed, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	err = VerifyJoinReq(req, seed, issuerB)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	t.Run("make_cred_by_t_nodes", func(t *testing.T) {
+		cred, err := ThresholdMakeCred(nodes[1:], group, seed, req, issuerB, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = VerifyCred(cred, &group.Ipk)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		signer := NewSWSigner(cred, sk)
+
+		signature, err := signer.Sign([]byte("hoge"), []byte("fuga"), rng)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+
+		err = Verify([]byte("hoge"), []byte("fuga"), signature, &group.Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	})
+
+	t.Run("make_cred_by_less_than_t_nodes", func(t *testing.T) {
+		_, err := ThresholdMakeCred(nodes[:1], group, seed, req, issuerB, rng)
+		if err == nil {
+			t.Fatalf("credential is made by less than t nodes")
+		}
+	})
+
+	t.Run("invalid_partial_credential", func(t *testing.T) {
+		session := NewCredentialSession(seed, req, issuerB, rng)
+
+		partials := []*PartialCredential{}
+
+		for _, node := range nodes[:2] {
+			partial, err := node.PartialCredBD(session)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			partials = append(partials, partial)
+		}
+
+		partials[1].B = partials[0].B
+
+		err := session.CombineBD(partials, group)
+		if err == nil {
+			t.Fatalf("invalid partial credential is accepted")
+		}
+	})
+
+	t.Run("forged_request", func(t *testing.T) {
+		// Q of the coordinator without the proof of the member
+		forged := *req
+		forged.Q = issuerB.Mul(amcl_utils.RandomBig(rng))
+
+		_, err := nodes[0].PartialCredBD(NewCredentialSession(seed, &forged, issuerB, rng))
+		if err == nil {
+			t.Fatalf("node signs forged join request")
+		}
+
+		// A of other point than the seed
+		other, otherB, err := GenJoinSeed(rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = nodes[0].PartialCredBD(NewCredentialSession(seed, req, otherB, rng))
+		if err == nil {
+			t.Fatalf("node signs A which is not of the seed")
+		}
+
+		_, err = nodes[0].PartialCredBD(NewCredentialSession(other, req, otherB, rng))
+		if err == nil {
+			t.Fatalf("node signs join request of other seed")
+		}
+
+		session := NewCredentialSession(seed, req, issuerB, rng)
+
+		partials := []*PartialCredential{}
+
+		for _, node := range nodes[:2] {
+			partial, err := node.PartialCredBD(session)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			partials = append(partials, partial)
+		}
+
+		err = session.CombineBD(partials, group)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		session.D = otherB
+
+		_, err = nodes[0].PartialCredC(session)
+		if err == nil {
+			t.Fatalf("node signs D which is not of the session")
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package tpm_utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-tpm/tpm2"
+)
+
+// TPM_CC_FIRST, the first command code
+const ccFirst = 0x0000011F
+
+/**
+ * Capabilities reported by TPM2_GetCapability.
+ */
+type Capabilities struct {
+	Curves     []tpm2.TPMECCCurve
+	Algorithms []tpm2.TPMAlgID
+	Commands   []tpm2.TPMCC
+	NVIndices  []tpm2.TPMHandle
+}
+
+/**
+ * A feature which the TPM must support for a join mode.
+ */
+type Requirement struct {
+	Name      string
+	Curve     tpm2.TPMECCCurve
+	Algorithm tpm2.TPMAlgID
+	Command   tpm2.TPMCC
+	NVIndex   tpm2.TPMHandle
+}
+
+// requirements of joining with the ECDAA key in the TPM
+var ECDAARequirements = []Requirement{
+	{Name: "curve BN_P256", Curve: tpm2.TPMECCBNP256},
+	{Name: "algorithm ECDAA", Algorithm: tpm2.TPMAlgECDAA},
+	{Name: "command TPM2_Commit", Command: tpm2.TPMCCCommit},
+	{Name: "command TPM2_Sign", Command: tpm2.TPMCCSign},
+	{Name: "command TPM2_ActivateCredential", Command: tpm2.TPMCCActivateCredential},
+	{Name: "command TPM2_NV_Read", Command: tpm2.TPMCCNVRead},
+	{Name: "EK certificate NV index", NVIndex: tpm2.TPMHandle(EK_CERT_INDEX)},
+}
+
+// requirements of sealing the software key to the TPM
+var SealRequirements = []Requirement{
+	{Name: "algorithm KEYEDHASH", Algorithm: tpm2.TPMAlgKeyedHash},
+	{Name: "command TPM2_Create", Command: tpm2.TPMCCCreate},
+	{Name: "command TPM2_Unseal", Command: tpm2.TPMCCUnseal},
+}
+
+/**
+ * Error which reports the requirements the TPM lacks.
+ */
+type UnsupportedError struct {
+	Missing []string
+}
+
+func (err *UnsupportedError) Error() string {
+	return fmt.Sprintf("TPM does not support: %v", strings.Join(err.Missing, ", "))
+}
+
+func (caps *Capabilities) HasCurve(curve tpm2.TPMECCCurve) bool {
+	for _, c := range caps.Curves {
+		if c == curve {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (caps *Capabilities) HasAlgorithm(alg tpm2.TPMAlgID) bool {
+	for _, a := range caps.Algorithms {
+		if a == alg {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (caps *Capabilities) HasCommand(cc tpm2.TPMCC) bool {
+	for _, c := range caps.Commands {
+		if c == cc {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (caps *Capabilities) HasNVIndex(index tpm2.TPMHandle) bool {
+	for _, i := range caps.NVIndices {
+		if i == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (caps *Capabilities) satisfies(req *Requirement) bool {
+	switch {
+	case req.Curve != 0:
+		return caps.HasCurve(req.Curve)
+	case req.Algorithm != 0:
+		return caps.HasAlgorithm(req.Algorithm)
+	case req.Command != 0:
+		return caps.HasCommand(req.Command)
+	case req.NVIndex != 0:
+		return caps.HasNVIndex(req.NVIndex)
+	}
+
+	return true
+}
+
+/**
+ * Names of the requirements which the TPM lacks.
+ */
+func (caps *Capabilities) Missing(reqs []Requirement) []string {
+	missing := []string{}
+
+	for i := range reqs {
+		if !caps.satisfies(&reqs[i]) {
+			missing = append(missing, reqs[i].Name)
+		}
+	}
+
+	return missing
+}
+
+/**
+ * Check the TPM satisfies the requirements, or return UnsupportedError.
+ */
+func (caps *Capabilities) Check(reqs []Requirement) error {
+	missing := caps.Missing(reqs)
+
+	if len(missing) != 0 {
+		return &UnsupportedError{Missing: missing}
+	}
+
+	return nil
+}
+
+// run TPM2_GetCapability until no more data is left;
+// collect returns the last property and the number of the properties in the page
+func (tpm *TPM) getCapability(capability tpm2.TPMCap, property uint32, collect func(*tpm2.TPMUCapabilities) (uint32, int, error)) error {
+	for {
+		getCap := tpm2.GetCapability{
+			Capability:    capability,
+			Property:      property,
+			PropertyCount: 256,
+		}
+
+		rsp, err := getCap.Execute(tpm.tpm)
+		if err != nil {
+			return fmt.Errorf("get capability %v: %v", capability, err)
+		}
+
+		last, count, err := collect(&rsp.CapabilityData.Data)
+		if err != nil {
+			return fmt.Errorf("get capability %v: %v", capability, err)
+		}
+
+		// an empty or non-advancing page would repeat forever with MoreData
+		if !rsp.MoreData || count == 0 || last < property {
+			return nil
+		}
+
+		property = last + 1
+	}
+}
+
+/**
+ * Probe the curves, algorithms, commands and NV indices of the TPM.
+ */
+func (tpm *TPM) Capabilities() (*Capabilities, error) {
+	var caps Capabilities
+
+	err := tpm.getCapability(tpm2.TPMCapECCCurves, 0, func(data *tpm2.TPMUCapabilities) (uint32, int, error) {
+		curves, err := data.ECCCurves()
+		if err != nil || len(curves.ECCCurves) == 0 {
+			return 0, 0, err
+		}
+
+		caps.Curves = append(caps.Curves, curves.ECCCurves...)
+
+		return uint32(curves.ECCCurves[len(curves.ECCCurves)-1]), len(curves.ECCCurves), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tpm.getCapability(tpm2.TPMCapAlgs, 0, func(data *tpm2.TPMUCapabilities) (uint32, int, error) {
+		algs, err := data.Algorithms()
+		if err != nil || len(algs.AlgProperties) == 0 {
+			return 0, 0, err
+		}
+
+		for _, prop := range algs.AlgProperties {
+			caps.Algorithms = append(caps.Algorithms, prop.Alg)
+		}
+
+		return uint32(algs.AlgProperties[len(algs.AlgProperties)-1].Alg), len(algs.AlgProperties), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tpm.getCapability(tpm2.TPMCapCommands, ccFirst, func(data *tpm2.TPMUCapabilities) (uint32, int, error) {
+		commands, err := data.Command()
+		if err != nil || len(commands.CommandAttributes) == 0 {
+			return 0, 0, err
+		}
+
+		for _, attr := range commands.CommandAttributes {
+			caps.Commands = append(caps.Commands, tpm2.TPMCC(attr.CommandIndex))
+		}
+
+		return uint32(commands.CommandAttributes[len(commands.CommandAttributes)-1].CommandIndex), len(commands.CommandAttributes), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tpm.getCapability(tpm2.TPMCapHandles, uint32(tpm2.TPMHTNVIndex)<<24, func(data *tpm2.TPMUCapabilities) (uint32, int, error) {
+		handles, err := data.Handles()
+		if err != nil || len(handles.Handle) == 0 {
+			return 0, 0, err
+		}
+
+		caps.NVIndices = append(caps.NVIndices, handles.Handle...)
+
+		return uint32(handles.Handle[len(handles.Handle)-1]), len(handles.Handle), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &caps, nil
+}
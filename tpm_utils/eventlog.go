@@ -0,0 +1,418 @@
+package tpm_utils
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/google/go-tpm/tpm2"
+)
+
+/**
+ * TCG PC Client event types used by the policies.
+ */
+const (
+	EV_POST_CODE                     = 0x00000001
+	EV_NO_ACTION                     = 0x00000003
+	EV_SEPARATOR                     = 0x00000004
+	EV_S_CRTM_CONTENTS               = 0x00000007
+	EV_S_CRTM_VERSION                = 0x00000008
+	EV_EFI_VARIABLE_DRIVER_CONFIG    = 0x80000001
+	EV_EFI_VARIABLE_BOOT             = 0x80000002
+	EV_EFI_BOOT_SERVICES_APPLICATION = 0x80000003
+	EV_EFI_ACTION                    = 0x80000007
+	EV_EFI_PLATFORM_FIRMWARE_BLOB    = 0x80000008
+	EV_EFI_PLATFORM_FIRMWARE_BLOB2   = 0x8000000A
+	EV_EFI_VARIABLE_AUTHORITY        = 0x800000E0
+)
+
+const (
+	specIDSignature          = "Spec ID Event03\x00"
+	startupLocalitySignature = "StartupLocality\x00"
+
+	// upper bounds against broken logs
+	maxEventSize   = 1 << 24
+	maxDigestCount = 16
+)
+
+/**
+ * An event of the log with the digests of the banks.
+ */
+type Event struct {
+	PCR     uint32
+	Type    uint32
+	Digests map[tpm2.TPMAlgID][]byte
+	Data    []byte
+}
+
+/**
+ * Parsed TCG PC Client event log in the crypto-agile format.
+ * Algorithms are the digest algorithms of the Spec ID event.
+ */
+type EventLog struct {
+	Algorithms map[tpm2.TPMAlgID]int
+	Events     []Event
+}
+
+type logReader struct {
+	buf *bytes.Reader
+}
+
+func (r *logReader) uint8() (uint8, error) {
+	var v uint8
+	err := binary.Read(r.buf, binary.LittleEndian, &v)
+	return v, err
+}
+
+func (r *logReader) uint16() (uint16, error) {
+	var v uint16
+	err := binary.Read(r.buf, binary.LittleEndian, &v)
+	return v, err
+}
+
+func (r *logReader) uint32() (uint32, error) {
+	var v uint32
+	err := binary.Read(r.buf, binary.LittleEndian, &v)
+	return v, err
+}
+
+func (r *logReader) bytes(size int) ([]byte, error) {
+	if size < 0 || size > r.buf.Len() {
+		return nil, fmt.Errorf("%v bytes are required but %v are left", size, r.buf.Len())
+	}
+
+	b := make([]byte, size)
+	_, err := r.buf.Read(b)
+
+	return b, err
+}
+
+// parse the Spec ID event (TCG_EfiSpecIDEvent) in the first TCG_PCR_EVENT
+func parseSpecID(data []byte) (map[tpm2.TPMAlgID]int, error) {
+	if len(data) < len(specIDSignature) || string(data[:len(specIDSignature)]) != specIDSignature {
+		return nil, fmt.Errorf("event log is not crypto-agile format")
+	}
+
+	r := logReader{bytes.NewReader(data[len(specIDSignature):])}
+
+	// platformClass, specVersionMinor, specVersionMajor, specErrata, uintnSize
+	_, err := r.bytes(8)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	if count == 0 || count > maxDigestCount {
+		return nil, fmt.Errorf("invalid number of algorithms: %v", count)
+	}
+
+	algs := map[tpm2.TPMAlgID]int{}
+
+	for i := uint32(0); i < count; i++ {
+		alg, err := r.uint16()
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := r.uint16()
+		if err != nil {
+			return nil, err
+		}
+
+		algs[tpm2.TPMAlgID(alg)] = int(size)
+	}
+
+	return algs, nil
+}
+
+/**
+ * Parse the TCG PC Client binary event log in the crypto-agile format
+ * (the first event is the SHA-1 Spec ID event, TCG_PCR_EVENT2 follow).
+ */
+func ParseEventLog(data []byte) (*EventLog, error) {
+	r := logReader{bytes.NewReader(data)}
+
+	// TCG_PCR_EVENT: pcrIndex, eventType, digest (SHA-1), eventSize, event
+	header, err := r.bytes(4 + 4 + 20)
+	if err != nil {
+		return nil, fmt.Errorf("spec ID event: %v", err)
+	}
+
+	if binary.LittleEndian.Uint32(header[4:8]) != EV_NO_ACTION {
+		return nil, fmt.Errorf("first event is not EV_NO_ACTION")
+	}
+
+	size, err := r.uint32()
+	if err != nil {
+		return nil, fmt.Errorf("spec ID event: %v", err)
+	}
+
+	specID, err := r.bytes(int(size))
+	if err != nil {
+		return nil, fmt.Errorf("spec ID event: %v", err)
+	}
+
+	algs, err := parseSpecID(specID)
+	if err != nil {
+		return nil, err
+	}
+
+	log := EventLog{Algorithms: algs}
+
+	for r.buf.Len() > 0 {
+		event, err := parseEvent2(&r, algs)
+		if err != nil {
+			return nil, fmt.Errorf("event %v: %v", len(log.Events), err)
+		}
+
+		log.Events = append(log.Events, *event)
+	}
+
+	return &log, nil
+}
+
+// parse TCG_PCR_EVENT2
+func parseEvent2(r *logReader, algs map[tpm2.TPMAlgID]int) (*Event, error) {
+	var event Event
+	var err error
+
+	event.PCR, err = r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	event.Type, err = r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	if count > maxDigestCount {
+		return nil, fmt.Errorf("invalid number of digests: %v", count)
+	}
+
+	event.Digests = map[tpm2.TPMAlgID][]byte{}
+
+	for i := uint32(0); i < count; i++ {
+		alg, err := r.uint16()
+		if err != nil {
+			return nil, err
+		}
+
+		size, ok := algs[tpm2.TPMAlgID(alg)]
+		if !ok {
+			return nil, fmt.Errorf("digest algorithm 0x%04x is not in spec ID event", alg)
+		}
+
+		event.Digests[tpm2.TPMAlgID(alg)], err = r.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	size, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	if size > maxEventSize {
+		return nil, fmt.Errorf("event is too large: %v", size)
+	}
+
+	event.Data, err = r.bytes(int(size))
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func hashOf(alg tpm2.TPMAlgID) (crypto.Hash, error) {
+	switch alg {
+	case tpm2.TPMAlgSHA1:
+		return crypto.SHA1, nil
+	case tpm2.TPMAlgSHA256:
+		return crypto.SHA256, nil
+	case tpm2.TPMAlgSHA384:
+		return crypto.SHA384, nil
+	case tpm2.TPMAlgSHA512:
+		return crypto.SHA512, nil
+	}
+
+	return 0, fmt.Errorf("digest algorithm 0x%04x is not supported", uint16(alg))
+}
+
+/**
+ * Replay the events into the PCR values of the bank.
+ * PCR 0 starts from the locality of the StartupLocality event if it exists.
+ */
+func (log *EventLog) Replay(alg tpm2.TPMAlgID) (map[uint][]byte, error) {
+	hash, err := hashOf(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := log.Algorithms[alg]; !ok {
+		return nil, fmt.Errorf("event log has no bank of 0x%04x", uint16(alg))
+	}
+
+	pcrs := map[uint][]byte{}
+
+	for _, event := range log.Events {
+		pcr := uint(event.PCR)
+
+		if _, ok := pcrs[pcr]; !ok {
+			pcrs[pcr] = make([]byte, hash.Size())
+		}
+
+		if event.Type == EV_NO_ACTION {
+			prefix := len(startupLocalitySignature)
+
+			if pcr == 0 && len(event.Data) > prefix && string(event.Data[:prefix]) == startupLocalitySignature {
+				pcrs[0][hash.Size()-1] = event.Data[prefix]
+			}
+
+			continue
+		}
+
+		digest, ok := event.Digests[alg]
+		if !ok {
+			return nil, fmt.Errorf("event of PCR %v has no digest of 0x%04x", pcr, uint16(alg))
+		}
+
+		h := hash.New()
+		h.Write(pcrs[pcr])
+		h.Write(digest)
+		pcrs[pcr] = h.Sum(nil)
+	}
+
+	return pcrs, nil
+}
+
+/**
+ * Value of the PCR after reset: PCR 17-22 (DRTM) are all ones, the others zero.
+ */
+func pcrResetValue(pcr uint, size int) []byte {
+	value := make([]byte, size)
+
+	if 17 <= pcr && pcr <= 22 {
+		for i := range value {
+			value[i] = 0xff
+		}
+	}
+
+	return value
+}
+
+/**
+ * Verify the replayed PCR values are the same as the quoted values.
+ * Every quoted PCR must match; a PCR which the log does not measure must
+ * be at its reset value, so events can not be dropped from the log.
+ * The events of the PCRs which are not quoted can not be trusted.
+ */
+func (log *EventLog) VerifyReplay(alg tpm2.TPMAlgID, quoted map[uint][]byte) error {
+	replayed, err := log.Replay(alg)
+	if err != nil {
+		return err
+	}
+
+	hash, err := hashOf(alg)
+	if err != nil {
+		return err
+	}
+
+	for pcr, want := range quoted {
+		value, ok := replayed[pcr]
+		if !ok {
+			value = pcrResetValue(pcr, hash.Size())
+		}
+
+		if !bytes.Equal(value, want) {
+			return fmt.Errorf("replayed PCR %v is not match: %x != %x", pcr, value, want)
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Events of the PCRs, leaving out EV_NO_ACTION.
+ */
+func (log *EventLog) EventsOf(pcrs ...uint) []Event {
+	events := []Event{}
+
+	for _, event := range log.Events {
+		if event.Type == EV_NO_ACTION {
+			continue
+		}
+
+		for _, pcr := range pcrs {
+			if uint(event.PCR) == pcr {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+
+	return events
+}
+
+/**
+ * EFI_GLOBAL_VARIABLE GUID (8BE4DF61-93CA-11D2-AA0D-00E098032B8C)
+ * in the byte order of UEFI_VARIABLE_DATA.
+ */
+var EFIGlobalVariable = [16]byte{
+	0x61, 0xdf, 0xe4, 0x8b, 0xca, 0x93, 0xd2, 0x11,
+	0xaa, 0x0d, 0x00, 0xe0, 0x98, 0x03, 0x2b, 0x8c,
+}
+
+/**
+ * UEFI_VARIABLE_DATA measured in EV_EFI_VARIABLE_* events.
+ */
+type EFIVariable struct {
+	VariableName [16]byte
+	UnicodeName  string
+	Data         []byte
+}
+
+func ParseEFIVariable(data []byte) (*EFIVariable, error) {
+	var variable EFIVariable
+
+	if len(data) < 32 {
+		return nil, fmt.Errorf("EFI variable is too short: %v", len(data))
+	}
+
+	copy(variable.VariableName[:], data[:16])
+
+	nameLength := binary.LittleEndian.Uint64(data[16:24])
+	dataLength := binary.LittleEndian.Uint64(data[24:32])
+
+	if nameLength > maxEventSize || dataLength > maxEventSize || uint64(len(data)-32) < nameLength*2+dataLength {
+		return nil, fmt.Errorf("EFI variable lengths exceed data")
+	}
+
+	name := make([]uint16, nameLength)
+	for i := range name {
+		name[i] = binary.LittleEndian.Uint16(data[32+2*i:])
+	}
+
+	variable.UnicodeName = string(utf16.Decode(name))
+
+	offset := 32 + 2*nameLength
+	variable.Data = data[offset : offset+dataLength]
+
+	return &variable, nil
+}
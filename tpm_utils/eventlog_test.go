@@ -0,0 +1,123 @@
+package tpm_utils
+
+import (
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/google/go-tpm/tpm2"
+)
+
+func readEventLog(t *testing.T, name string) []byte {
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return data
+}
+
+func TestParseEventLog(t *testing.T) {
+	for _, name := range []string{
+		"sb_cert_eventlog",
+		"ubuntu_2104_shielded_vm_no_secure_boot_eventlog",
+		"coreos_36_shielded_vm_no_secure_boot_eventlog",
+	} {
+		t.Run(name, func(t *testing.T) {
+			log, err := ParseEventLog(readEventLog(t, name))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			if _, ok := log.Algorithms[tpm2.TPMAlgSHA256]; !ok {
+				t.Fatalf("no SHA-256 bank: %v", log.Algorithms)
+			}
+
+			if len(log.Events) == 0 {
+				t.Fatalf("no events")
+			}
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		data := readEventLog(t, "sb_cert_eventlog")
+
+		_, err := ParseEventLog(data[:len(data)-1])
+		if err == nil {
+			t.Fatalf("parsed truncated log")
+		}
+	})
+
+	t.Run("not_crypto_agile", func(t *testing.T) {
+		_, err := ParseEventLog(make([]byte, 64))
+		if err == nil {
+			t.Fatalf("parsed broken log")
+		}
+	})
+}
+
+func TestReplayEventLog(t *testing.T) {
+	log, err := ParseEventLog(readEventLog(t, "sb_cert_eventlog"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// PCR values read from the TPM the log was captured on
+	quoted := map[uint][]byte{}
+
+	for pcr, value := range map[uint]string{
+		0: "fcecb56acc303862b30eb342c4990beb50b5e0ab89722449c2d9a73f37b019fe",
+		4: "a92968806f795fa34435d9f11813684ca1e7056077f700ba49f26f9962f86d89",
+		5: "cc8618b77932b4efda12cc58bad93ecdd1959dea29e5ab794525a619f5baabee",
+		7: "51b30488c9e6255d822bdc1b20d9a92c32bde6c3e7bc02bcdd32825eb5ef069a",
+	} {
+		quoted[pcr], _ = hex.DecodeString(value)
+	}
+
+	err = log.VerifyReplay(tpm2.TPMAlgSHA256, quoted)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	t.Run("unmeasured", func(t *testing.T) {
+		// PCR 8 is not measured in the log, so it must be at reset
+		unmeasured := map[uint][]byte{8: make([]byte, 32)}
+
+		err := log.VerifyReplay(tpm2.TPMAlgSHA256, unmeasured)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		unmeasured[8] = quoted[0]
+
+		err = log.VerifyReplay(tpm2.TPMAlgSHA256, unmeasured)
+		if err == nil {
+			t.Fatalf("replay matched extended PCR without events")
+		}
+	})
+
+	t.Run("stripped", func(t *testing.T) {
+		stripped := *log
+		stripped.Events = []Event{}
+
+		for _, event := range log.Events {
+			if event.PCR != 0 {
+				stripped.Events = append(stripped.Events, event)
+			}
+		}
+
+		err := stripped.VerifyReplay(tpm2.TPMAlgSHA256, quoted)
+		if err == nil {
+			t.Fatalf("replay matched log without PCR 0 events")
+		}
+	})
+
+	t.Run("wrong", func(t *testing.T) {
+		wrong := map[uint][]byte{7: make([]byte, 32)}
+
+		err := log.VerifyReplay(tpm2.TPMAlgSHA256, wrong)
+		if err == nil {
+			t.Fatalf("replay matched wrong PCR value")
+		}
+	})
+}
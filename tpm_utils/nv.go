@@ -0,0 +1,203 @@
+package tpm_utils
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/google/go-tpm/tpm2"
+)
+
+// chunk size used if TPM_PT_NV_BUFFER_MAX can not be read
+const defaultNVBufferMax = 512
+
+/**
+ * EK certificate NV index and the kind of the EK it certifies.
+ */
+type EKCertIndex struct {
+	Index tpm2.TPMHandle
+	Name  string
+}
+
+/**
+ * All EK certificate NV indices, in the order they are looked up.
+ */
+var EKCertIndices = []EKCertIndex{
+	{Index: EK_CERT_INDEX_RSA2048, Name: "RSA 2048"},
+	{Index: EK_CERT_INDEX_ECC_P256, Name: "ECC NIST P-256"},
+	{Index: EK_CERT_INDEX_HIGH_RSA2048, Name: "RSA 2048 (high range)"},
+	{Index: EK_CERT_INDEX_HIGH_ECC_P256, Name: "ECC NIST P-256 (high range)"},
+	{Index: EK_CERT_INDEX_HIGH_ECC_P384, Name: "ECC NIST P-384 (high range)"},
+	{Index: EK_CERT_INDEX_HIGH_ECC_P521, Name: "ECC NIST P-521 (high range)"},
+	{Index: EK_CERT_INDEX_HIGH_ECC_SM2, Name: "ECC SM2 P-256 (high range)"},
+	{Index: EK_CERT_INDEX_HIGH_RSA3072, Name: "RSA 3072 (high range)"},
+	{Index: EK_CERT_INDEX_HIGH_RSA4096, Name: "RSA 4096 (high range)"},
+}
+
+/**
+ * Size of the DER element at the head of data, including its header.
+ * The NV indices are larger than the certificates and padded (often with 0xFF),
+ * so the true size is taken from the DER length instead of the data.
+ */
+func DERLength(data []byte) (int, error) {
+	if len(data) < 2 {
+		return 0, fmt.Errorf("DER header is too short: %v", len(data))
+	}
+
+	if data[0] != 0x30 {
+		return 0, fmt.Errorf("DER is not a SEQUENCE: 0x%02x", data[0])
+	}
+
+	header := 2
+	length := int(data[1])
+
+	if length&0x80 != 0 {
+		n := length & 0x7f
+
+		if n == 0 || n > 4 {
+			return 0, fmt.Errorf("DER length is not supported: 0x%02x", data[1])
+		}
+
+		if len(data) < 2+n {
+			return 0, fmt.Errorf("DER header is too short: %v", len(data))
+		}
+
+		length = 0
+		for _, b := range data[2 : 2+n] {
+			length = length<<8 | int(b)
+		}
+
+		header += n
+	}
+
+	if header+length > len(data) {
+		return 0, fmt.Errorf("DER length exceeds data: %v > %v", header+length, len(data))
+	}
+
+	return header + length, nil
+}
+
+// TPM_PT_NV_BUFFER_MAX, the maximum size of a single NV read
+func (tpm *TPM) nvBufferMax() int {
+	getCap := tpm2.GetCapability{
+		Capability:    tpm2.TPMCapTPMProperties,
+		Property:      uint32(tpm2.TPMPTNVBufferMax),
+		PropertyCount: 1,
+	}
+
+	rsp, err := getCap.Execute(tpm.tpm)
+	if err != nil {
+		return defaultNVBufferMax
+	}
+
+	props, err := rsp.CapabilityData.Data.TPMProperties()
+	if err != nil || len(props.TPMProperty) == 0 {
+		return defaultNVBufferMax
+	}
+
+	prop := props.TPMProperty[0]
+	if prop.Property != tpm2.TPMPTNVBufferMax || prop.Value == 0 {
+		return defaultNVBufferMax
+	}
+
+	return int(prop.Value)
+}
+
+/**
+ * Read the whole data of the NV index in chunks of TPM_PT_NV_BUFFER_MAX.
+ */
+func (tpm *TPM) ReadNV(index tpm2.TPMHandle) ([]byte, error) {
+	readPub := tpm2.NVReadPublic{
+		NVIndex: index,
+	}
+
+	rspRP, err := readPub.Execute(tpm.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("read public: %w", err)
+	}
+
+	nvPublic, err := rspRP.NVPublic.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("read public: %w", err)
+	}
+
+	handle := tpm2.NamedHandle{
+		Handle: nvPublic.NVIndex,
+		Name:   rspRP.NVName,
+	}
+
+	size := int(nvPublic.DataSize)
+	chunk := tpm.nvBufferMax()
+	result := make([]byte, 0, size)
+
+	for offset := 0; offset < size; offset += chunk {
+		readSize := chunk
+		if size-offset < readSize {
+			readSize = size - offset
+		}
+
+		read := tpm2.NVRead{
+			AuthHandle: handle,
+			NVIndex:    handle,
+			Size:       uint16(readSize),
+			Offset:     uint16(offset),
+		}
+
+		rspNV, err := read.Execute(tpm.tpm)
+		if err != nil {
+			return nil, fmt.Errorf("read at %v: %w", offset, err)
+		}
+
+		result = append(result, rspNV.Data.Buffer...)
+	}
+
+	return result, nil
+}
+
+/**
+ * Read and parse the EK certificate in the NV index.
+ */
+func (tpm *TPM) ReadEKCertAt(index tpm2.TPMHandle) (*x509.Certificate, error) {
+	data, err := tpm.ReadNV(index)
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := DERLength(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing EK cert: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(data[:length])
+	if err != nil {
+		return nil, fmt.Errorf("parsing EK cert: %v", err)
+	}
+
+	return cert, nil
+}
+
+/**
+ * Read the RSA 2048 EK certificate, which certifies the EK used in join.
+ */
+func (tpm *TPM) ReadEKCert() (*x509.Certificate, error) {
+	return tpm.ReadEKCertAt(EK_CERT_INDEX)
+}
+
+/**
+ * EK certificate NV indices which exist in the TPM.
+ */
+func (tpm *TPM) ListEKCerts() ([]EKCertIndex, error) {
+	caps, err := tpm.Capabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	found := []EKCertIndex{}
+
+	for _, index := range EKCertIndices {
+		if caps.HasNVIndex(index.Index) {
+			found = append(found, index)
+		}
+	}
+
+	return found, nil
+}
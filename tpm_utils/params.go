@@ -1,3 +1,33 @@
 package tpm_utils
 
 const EK_CERT_INDEX = 0x01C00002
+
+/**
+ * NV indices of the EK certificates defined by TCG EK Credential Profile.
+ */
+const (
+	EK_CERT_INDEX_RSA2048  = 0x01C00002
+	EK_CERT_INDEX_ECC_P256 = 0x01C0000A
+
+	// high range
+	EK_CERT_INDEX_HIGH_RSA2048  = 0x01C00012
+	EK_CERT_INDEX_HIGH_ECC_P256 = 0x01C00014
+	EK_CERT_INDEX_HIGH_ECC_P384 = 0x01C00016
+	EK_CERT_INDEX_HIGH_ECC_P521 = 0x01C00018
+	EK_CERT_INDEX_HIGH_ECC_SM2  = 0x01C0001A
+	EK_CERT_INDEX_HIGH_RSA3072  = 0x01C0001C
+	EK_CERT_INDEX_HIGH_RSA4096  = 0x01C0001E
+)
+
+/**
+ * NV index ranges of the certificates other than the EK certificates.
+ */
+const (
+	// EK certificate chain (intermediate CAs), TCG EK Credential Profile
+	EK_CERT_CHAIN_INDEX_FIRST = 0x01C00100
+	EK_CERT_CHAIN_INDEX_LAST  = 0x01C001FF
+
+	// TCG platform certificates (attribute certificates of the platform)
+	PLATFORM_CERT_INDEX_FIRST = 0x01C08000
+	PLATFORM_CERT_INDEX_LAST  = 0x01C0FFFF
+)
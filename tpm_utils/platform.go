@@ -0,0 +1,98 @@
+package tpm_utils
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-tpm/tpm2"
+)
+
+/**
+ * Split the concatenated DER elements, stopping at the padding
+ * (0x00 or 0xFF) which fills the rest of the NV index.
+ */
+func SplitDER(data []byte) ([][]byte, error) {
+	elements := [][]byte{}
+
+	for len(data) > 0 && data[0] != 0x00 && data[0] != 0xFF {
+		length, err := DERLength(data)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, data[:length])
+		data = data[length:]
+	}
+
+	return elements, nil
+}
+
+// read the DER elements in the NV indices of the range which exist;
+// the indices which can not be read or split are skipped and reported in the error
+func (tpm *TPM) readDERRange(first, last tpm2.TPMHandle) ([][]byte, error) {
+	caps, err := tpm.Capabilities()
+	if err != nil {
+		return [][]byte{}, err
+	}
+
+	elements := [][]byte{}
+	errs := []error{}
+
+	for _, index := range caps.NVIndices {
+		if index < first || last < index {
+			continue
+		}
+
+		data, err := tpm.ReadNV(index)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("NV index 0x%08x: %v", uint32(index), err))
+			continue
+		}
+
+		split, err := SplitDER(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("NV index 0x%08x: %v", uint32(index), err))
+			continue
+		}
+
+		elements = append(elements, split...)
+	}
+
+	return elements, errors.Join(errs...)
+}
+
+/**
+ * Read the intermediate CA certificates of the EK certificate stored in the TPM.
+ * An NV index of the chain may hold several certificates concatenated.
+ * The certificates which can not be read or parsed are skipped and
+ * reported in the error, along with the ones read.
+ */
+func (tpm *TPM) ReadEKCertChain() ([]*x509.Certificate, error) {
+	elements, err := tpm.readDERRange(EK_CERT_CHAIN_INDEX_FIRST, EK_CERT_CHAIN_INDEX_LAST)
+	errs := []error{err}
+
+	certs := []*x509.Certificate{}
+
+	for _, element := range elements {
+		cert, err := x509.ParseCertificate(element)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing EK chain cert: %v", err))
+			continue
+		}
+
+		certs = append(certs, cert)
+	}
+
+	return certs, errors.Join(errs...)
+}
+
+/**
+ * Read the TCG platform certificates stored in the TPM.
+ * They are X.509 attribute certificates, which crypto/x509 can not parse,
+ * so they are returned as DER. As ReadEKCertChain, the ones read are
+ * returned with the error.
+ */
+func (tpm *TPM) ReadPlatformCerts() ([][]byte, error) {
+	return tpm.readDERRange(PLATFORM_CERT_INDEX_FIRST, PLATFORM_CERT_INDEX_LAST)
+}
@@ -0,0 +1,307 @@
+package tpm_utils
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/google/go-tpm/tpm2"
+)
+
+const EVENT_LOG_PATH = "/sys/kernel/security/tpm0/binary_bios_measurements"
+
+/**
+ * TPM2_Quote over PCRs with the attestation key.
+ * Quoted and Signature are the marshalled TPMS_ATTEST and TPMT_SIGNATURE,
+ * PCRs are the SHA-256 values of the quoted PCRs.
+ */
+type Quote struct {
+	Quoted    []byte
+	Signature []byte
+	PCRs      map[uint][]byte
+}
+
+func akTemplate() tpm2.TPMTPublic {
+	return tpm2.TPMTPublic{
+		Type:    tpm2.TPMAlgECC,
+		NameAlg: tpm2.TPMAlgSHA256,
+		ObjectAttributes: tpm2.TPMAObject{
+			FixedTPM:            true,
+			FixedParent:         true,
+			SensitiveDataOrigin: true,
+			UserWithAuth:        true,
+			Restricted:          true,
+			SignEncrypt:         true,
+		},
+		Parameters: tpm2.NewTPMUPublicParms(
+			tpm2.TPMAlgECC,
+			&tpm2.TPMSECCParms{
+				Scheme: tpm2.TPMTECCScheme{
+					Scheme: tpm2.TPMAlgECDSA,
+					Details: tpm2.NewTPMUAsymScheme(
+						tpm2.TPMAlgECDSA,
+						&tpm2.TPMSSigSchemeECDSA{
+							HashAlg: tpm2.TPMAlgSHA256,
+						},
+					),
+				},
+				CurveID: tpm2.TPMECCNistP256,
+			},
+		),
+	}
+}
+
+/**
+ * Create the attestation key (restricted ECDSA P-256 signing key).
+ * The AK takes the place of the SRK in ActivateCredential,
+ * so the credential certifies it lives in the TPM of the EK.
+ */
+func (tpm *TPM) CreateAK() (*tpm2.NamedHandle, []byte, error) {
+	create := tpm2.CreatePrimary{
+		PrimaryHandle: tpm2.TPMRHOwner,
+		InPublic:      tpm2.New2B(akTemplate()),
+	}
+
+	rsp, err := create.Execute(tpm.tpm)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create AK: %v", err)
+	}
+
+	handle := tpm2.NamedHandle{
+		Handle: rsp.ObjectHandle,
+		Name:   rsp.Name,
+	}
+
+	return &handle, tpm2.Marshal(rsp.OutPublic), nil
+}
+
+/**
+ * Read the SHA-256 values of the PCRs.
+ */
+func (tpm *TPM) ReadPCRs(pcrs []uint) (map[uint][]byte, error) {
+	values := map[uint][]byte{}
+
+	sorted := append([]uint{}, pcrs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	// TPM2_PCR_Read returns at most 8 digests at once
+	for i := 0; i < len(sorted); i += 8 {
+		end := i + 8
+		if len(sorted) < end {
+			end = len(sorted)
+		}
+
+		read := tpm2.PCRRead{
+			PCRSelectionIn: pcrSelection(sorted[i:end]),
+		}
+
+		rsp, err := read.Execute(tpm.tpm)
+		if err != nil {
+			return nil, fmt.Errorf("read PCRs: %v", err)
+		}
+
+		if len(rsp.PCRValues.Digests) != end-i {
+			return nil, fmt.Errorf("read PCRs: %v values for %v PCRs", len(rsp.PCRValues.Digests), end-i)
+		}
+
+		for j, digest := range rsp.PCRValues.Digests {
+			values[sorted[i+j]] = digest.Buffer
+		}
+	}
+
+	return values, nil
+}
+
+/**
+ * Quote the PCRs with the AK, qualified by the nonce.
+ */
+func (tpm *TPM) Quote(ak *tpm2.NamedHandle, nonce []byte, pcrs []uint) (*Quote, error) {
+	values, err := tpm.ReadPCRs(pcrs)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := tpm2.Quote{
+		SignHandle: *ak,
+		QualifyingData: tpm2.TPM2BData{
+			Buffer: nonce,
+		},
+		InScheme: tpm2.TPMTSigScheme{
+			Scheme: tpm2.TPMAlgNull,
+		},
+		PCRSelect: pcrSelection(pcrs),
+	}
+
+	rsp, err := quote.Execute(tpm.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("quote: %v", err)
+	}
+
+	result := Quote{
+		Quoted:    rsp.Quoted.Bytes(),
+		Signature: tpm2.Marshal(rsp.Signature),
+		PCRs:      values,
+	}
+
+	return &result, nil
+}
+
+/**
+ * Read the TCG event log of the firmware.
+ */
+func ReadEventLog(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+/**
+ * Name of the object computed from its marshalled TPM2B_PUBLIC.
+ */
+func PublicName(public []byte) ([]byte, error) {
+	pub, err := tpm2.Unmarshal[tpm2.TPM2BPublic](public)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal public: %v", err)
+	}
+
+	contents, err := pub.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal public: %v", err)
+	}
+
+	name, err := tpm2.ObjectName(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	return name.Buffer, nil
+}
+
+/**
+ * Digest of the PCR values in the order of the PCR indices, as in TPMS_QUOTE_INFO.
+ */
+func PCRDigest(values map[uint][]byte) []byte {
+	pcrs := make([]uint, 0, len(values))
+
+	for pcr := range values {
+		pcrs = append(pcrs, pcr)
+	}
+
+	sort.Slice(pcrs, func(i, j int) bool { return pcrs[i] < pcrs[j] })
+
+	hash := sha256.New()
+
+	for _, pcr := range pcrs {
+		hash.Write(values[pcr])
+	}
+
+	return hash.Sum(nil)
+}
+
+func akPublicKey(public []byte) (*ecdsa.PublicKey, error) {
+	pub, err := tpm2.Unmarshal[tpm2.TPM2BPublic](public)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal public: %v", err)
+	}
+
+	contents, err := pub.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal public: %v", err)
+	}
+
+	if contents.Type != tpm2.TPMAlgECC || contents.NameAlg != tpm2.TPMAlgSHA256 {
+		return nil, fmt.Errorf("AK is not an ECC key named with SHA-256")
+	}
+
+	// a restricted key made outside of the TPM and imported lacks FixedTPM,
+	// and could sign the quotes outside of the TPM
+	if contents.ObjectAttributes != akTemplate().ObjectAttributes {
+		return nil, fmt.Errorf("attributes of AK are not of the AK template: %+v", contents.ObjectAttributes)
+	}
+
+	params, err := contents.Parameters.ECCDetail()
+	if err != nil {
+		return nil, err
+	}
+
+	point, err := contents.Unique.ECC()
+	if err != nil {
+		return nil, err
+	}
+
+	ecc, err := tpm2.ECCPub(params, point)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{Curve: ecc.Curve, X: ecc.X, Y: ecc.Y}, nil
+}
+
+/**
+ * Verify the quote is signed by the AK over the PCR values and the nonce.
+ */
+func VerifyQuote(akPublic []byte, quote *Quote, nonce []byte) error {
+	pub, err := akPublicKey(akPublic)
+	if err != nil {
+		return err
+	}
+
+	sig, err := tpm2.Unmarshal[tpm2.TPMTSignature](quote.Signature)
+	if err != nil {
+		return fmt.Errorf("unmarshal signature: %v", err)
+	}
+
+	ecdsaSig, err := sig.Signature.ECDSA()
+	if err != nil {
+		return fmt.Errorf("signature is not ECDSA: %v", err)
+	}
+
+	if ecdsaSig.Hash != tpm2.TPMAlgSHA256 {
+		return fmt.Errorf("signature hash is not SHA-256")
+	}
+
+	digest := sha256.Sum256(quote.Quoted)
+	r := new(big.Int).SetBytes(ecdsaSig.SignatureR.Buffer)
+	s := new(big.Int).SetBytes(ecdsaSig.SignatureS.Buffer)
+
+	if !ecdsa.Verify(pub, digest[:], r, s) {
+		return fmt.Errorf("quote signature is not valid")
+	}
+
+	attest, err := tpm2.Unmarshal[tpm2.TPMSAttest](quote.Quoted)
+	if err != nil {
+		return fmt.Errorf("unmarshal attest: %v", err)
+	}
+
+	if attest.Type != tpm2.TPMSTAttestQuote {
+		return fmt.Errorf("attest is not quote: %v", attest.Type)
+	}
+
+	if !bytes.Equal(attest.ExtraData.Buffer, nonce) {
+		return fmt.Errorf("nonce is not match")
+	}
+
+	info, err := attest.Attested.Quote()
+	if err != nil {
+		return fmt.Errorf("quote info: %v", err)
+	}
+
+	pcrs := make([]uint, 0, len(quote.PCRs))
+	for pcr := range quote.PCRs {
+		pcrs = append(pcrs, pcr)
+	}
+
+	want := pcrSelection(pcrs)
+
+	if !bytes.Equal(tpm2.Marshal(info.PCRSelect), tpm2.Marshal(want)) {
+		return fmt.Errorf("quoted PCRs are not match")
+	}
+
+	if !bytes.Equal(info.PCRDigest.Buffer, PCRDigest(quote.PCRs)) {
+		return fmt.Errorf("PCR digest is not match")
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package tpm_utils
+
+import (
+	"fmt"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpm2/transport"
+)
+
+/**
+ * Data sealed to the TPM under the SRK, optionally bound to PCR values.
+ * Public and Private are the marshalled TPM2B_PUBLIC and TPM2B_PRIVATE.
+ */
+type SealedBlob struct {
+	Public  []byte
+	Private []byte
+	PCRs    []uint
+}
+
+func pcrSelection(pcrs []uint) tpm2.TPMLPCRSelection {
+	return tpm2.TPMLPCRSelection{
+		PCRSelections: []tpm2.TPMSPCRSelection{
+			{
+				Hash:      tpm2.TPMAlgSHA256,
+				PCRSelect: tpm2.PCClientCompatible.PCRs(pcrs...),
+			},
+		},
+	}
+}
+
+// policy digest of TPM2_PolicyPCR over the current values of the PCRs
+func (tpm *TPM) pcrPolicyDigest(pcrs []uint) ([]byte, error) {
+	sess, closer, err := tpm2.PolicySession(tpm.tpm, tpm2.TPMAlgSHA256, 16, tpm2.Trial())
+	if err != nil {
+		return nil, fmt.Errorf("policy session: %v", err)
+	}
+	defer closer()
+
+	policyPCR := tpm2.PolicyPCR{
+		PolicySession: sess.Handle(),
+		Pcrs:          pcrSelection(pcrs),
+	}
+
+	_, err = policyPCR.Execute(tpm.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("policy PCR: %v", err)
+	}
+
+	getDigest := tpm2.PolicyGetDigest{
+		PolicySession: sess.Handle(),
+	}
+
+	rsp, err := getDigest.Execute(tpm.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("policy get digest: %v", err)
+	}
+
+	return rsp.PolicyDigest.Buffer, nil
+}
+
+/**
+ * Seal the data under the SRK.
+ * If pcrs are given, the data can be unsealed only while the PCRs
+ * have the same values as now.
+ */
+func (tpm *TPM) Seal(data []byte, pcrs []uint) (*SealedBlob, error) {
+	srkHandle, err := tpm.createSRK()
+	if err != nil {
+		return nil, err
+	}
+	defer tpm.flush(srkHandle.Handle)
+
+	var policy []byte
+
+	if len(pcrs) > 0 {
+		policy, err = tpm.pcrPolicyDigest(pcrs)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	template := tpm2.TPMTPublic{
+		Type:    tpm2.TPMAlgKeyedHash,
+		NameAlg: tpm2.TPMAlgSHA256,
+		ObjectAttributes: tpm2.TPMAObject{
+			FixedTPM:     true,
+			FixedParent:  true,
+			UserWithAuth: len(pcrs) == 0,
+			NoDA:         true,
+		},
+		AuthPolicy: tpm2.TPM2BDigest{
+			Buffer: policy,
+		},
+		Parameters: tpm2.NewTPMUPublicParms(
+			tpm2.TPMAlgKeyedHash,
+			&tpm2.TPMSKeyedHashParms{
+				Scheme: tpm2.TPMTKeyedHashScheme{
+					Scheme: tpm2.TPMAlgNull,
+				},
+			},
+		),
+	}
+
+	create := tpm2.Create{
+		ParentHandle: *srkHandle,
+		InSensitive: tpm2.TPM2BSensitiveCreate{
+			Sensitive: &tpm2.TPMSSensitiveCreate{
+				UserAuth: tpm2.TPM2BAuth{
+					Buffer: tpm.password,
+				},
+				Data: tpm2.NewTPMUSensitiveCreate(&tpm2.TPM2BSensitiveData{
+					Buffer: data,
+				}),
+			},
+		},
+		InPublic: tpm2.New2B(template),
+	}
+
+	rsp, err := create.Execute(tpm.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("create sealed object: %v", err)
+	}
+
+	blob := SealedBlob{
+		Public:  tpm2.Marshal(rsp.OutPublic),
+		Private: tpm2.Marshal(rsp.OutPrivate),
+		PCRs:    pcrs,
+	}
+
+	return &blob, nil
+}
+
+/**
+ * Unseal the data sealed by Seal.
+ */
+func (tpm *TPM) Unseal(blob *SealedBlob) ([]byte, error) {
+	srkHandle, err := tpm.createSRK()
+	if err != nil {
+		return nil, err
+	}
+	defer tpm.flush(srkHandle.Handle)
+
+	public, err := tpm2.Unmarshal[tpm2.TPM2BPublic](blob.Public)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal public: %v", err)
+	}
+
+	private, err := tpm2.Unmarshal[tpm2.TPM2BPrivate](blob.Private)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal private: %v", err)
+	}
+
+	load := tpm2.Load{
+		ParentHandle: *srkHandle,
+		InPrivate:    *private,
+		InPublic:     *public,
+	}
+
+	loadRsp, err := load.Execute(tpm.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("load sealed object: %v", err)
+	}
+	defer tpm.flush(loadRsp.ObjectHandle)
+
+	var auth tpm2.Session
+
+	if len(blob.PCRs) > 0 {
+		pcrs := blob.PCRs
+
+		auth = tpm2.Policy(tpm2.TPMAlgSHA256, 16, func(t transport.TPM, handle tpm2.TPMISHPolicy, _ tpm2.TPM2BNonce) error {
+			policyPCR := tpm2.PolicyPCR{
+				PolicySession: handle,
+				Pcrs:          pcrSelection(pcrs),
+			}
+
+			_, err := policyPCR.Execute(t)
+			return err
+		})
+	} else {
+		auth = tpm2.PasswordAuth(tpm.password)
+	}
+
+	unseal := tpm2.Unseal{
+		ItemHandle: tpm2.AuthHandle{
+			Handle: loadRsp.ObjectHandle,
+			Name:   loadRsp.Name,
+			Auth:   auth,
+		},
+	}
+
+	rsp, err := unseal.Execute(tpm.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("unseal: %v", err)
+	}
+
+	return rsp.OutData.Buffer, nil
+}
@@ -1,7 +1,6 @@
 package tpm_utils
 
 import (
-	"crypto/x509"
 	"fmt"
 
 	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
@@ -126,10 +125,7 @@ func (tpm *TPM) Close() {
 	tpm.tpm.Close()
 }
 
-func (tpm *TPM) CreateKey() (*tpm2.AuthHandle, *tpm2.AuthHandle, *tpm2.NamedHandle, *tpm2.TPM2BPublic, error) {
-	params := publicParams()
-	auth := tpm2.PasswordAuth(tpm.password)
-
+func (tpm *TPM) createEK() (*tpm2.AuthHandle, error) {
 	ekCreate := tpm2.CreatePrimary{
 		PrimaryHandle: tpm2.TPMRHEndorsement,
 		InPublic:      tpm2.New2B(tpm2.RSAEKTemplate),
@@ -137,9 +133,19 @@ func (tpm *TPM) CreateKey() (*tpm2.AuthHandle, *tpm2.AuthHandle, *tpm2.NamedHand
 
 	ekCreateRsp, err := ekCreate.Execute(tpm.tpm)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("create ek: %v", err)
+		return nil, fmt.Errorf("create ek: %v", err)
+	}
+
+	ekHandle := tpm2.AuthHandle{
+		Handle: ekCreateRsp.ObjectHandle,
+		Name:   ekCreateRsp.Name,
+		Auth:   tpm2.Policy(tpm2.TPMAlgSHA256, 16, ekPolicy),
 	}
 
+	return &ekHandle, nil
+}
+
+func (tpm *TPM) createSRK() (*tpm2.NamedHandle, error) {
 	srkCreate := tpm2.CreatePrimary{
 		PrimaryHandle: tpm2.TPMRHOwner,
 		InPublic:      tpm2.New2B(tpm2.ECCSRKTemplate),
@@ -147,7 +153,7 @@ func (tpm *TPM) CreateKey() (*tpm2.AuthHandle, *tpm2.AuthHandle, *tpm2.NamedHand
 
 	srkCreateRsp, err := srkCreate.Execute(tpm.tpm)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("create SRK: %v", err)
+		return nil, fmt.Errorf("create SRK: %v", err)
 	}
 
 	srkHandle := tpm2.NamedHandle{
@@ -155,6 +161,51 @@ func (tpm *TPM) CreateKey() (*tpm2.AuthHandle, *tpm2.AuthHandle, *tpm2.NamedHand
 		Name:   srkCreateRsp.Name,
 	}
 
+	return &srkHandle, nil
+}
+
+func (tpm *TPM) flush(handle tpm2.TPMHandle) {
+	flush := tpm2.FlushContext{FlushHandle: handle}
+	flush.Execute(tpm.tpm)
+}
+
+/**
+ * Flush the transient object of the handle from the TPM.
+ */
+func (tpm *TPM) Flush(handle tpm2.TPMHandle) {
+	tpm.flush(handle)
+}
+
+// explain the failure with the requirements which the TPM lacks
+func (tpm *TPM) unsupported(err error, reqs []Requirement) error {
+	caps, capErr := tpm.Capabilities()
+	if capErr != nil {
+		return err
+	}
+
+	missing := caps.Missing(reqs)
+	if len(missing) == 0 {
+		return err
+	}
+
+	return fmt.Errorf("%w: %v", &UnsupportedError{Missing: missing}, err)
+}
+
+func (tpm *TPM) CreateKey() (*tpm2.AuthHandle, *tpm2.AuthHandle, *tpm2.NamedHandle, *tpm2.TPM2BPublic, error) {
+	params := publicParams()
+	auth := tpm2.PasswordAuth(tpm.password)
+
+	ekHandle, err := tpm.createEK()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	srkHandle, err := tpm.createSRK()
+	if err != nil {
+		tpm.flush(ekHandle.Handle)
+		return nil, nil, nil, nil, err
+	}
+
 	create := tpm2.CreatePrimary{
 		PrimaryHandle: tpm2.TPMRHOwner,
 		InSensitive: tpm2.TPM2BSensitiveCreate{
@@ -167,15 +218,13 @@ func (tpm *TPM) CreateKey() (*tpm2.AuthHandle, *tpm2.AuthHandle, *tpm2.NamedHand
 		InPublic: tpm2.New2B(params.key),
 	}
 
-	ekHandle := tpm2.AuthHandle{
-		Handle: ekCreateRsp.ObjectHandle,
-		Name:   ekCreateRsp.Name,
-		Auth:   tpm2.Policy(tpm2.TPMAlgSHA256, 16, ekPolicy),
-	}
-
 	rspC, err := create.Execute(tpm.tpm)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("create: %v", err)
+		// without a resource manager, the fallback needs the slots of the transient objects
+		tpm.flush(ekHandle.Handle)
+		tpm.flush(srkHandle.Handle)
+
+		return nil, nil, nil, nil, tpm.unsupported(fmt.Errorf("create: %v", err), ECDAARequirements)
 	}
 
 	handle := tpm2.AuthHandle{
@@ -184,7 +233,7 @@ func (tpm *TPM) CreateKey() (*tpm2.AuthHandle, *tpm2.AuthHandle, *tpm2.NamedHand
 		Auth:   auth,
 	}
 
-	return &handle, &ekHandle, &srkHandle, &rspC.OutPublic, nil
+	return &handle, ekHandle, srkHandle, &rspC.OutPublic, nil
 }
 
 func (tpm *TPM) ActivateCredential(ekHandle *tpm2.AuthHandle, srkHandle *tpm2.NamedHandle, idObject, wrappedCredential []byte) ([]byte, error) {
@@ -213,72 +262,10 @@ func (tpm *TPM) ActivateCredential(ekHandle *tpm2.AuthHandle, srkHandle *tpm2.Na
 	return acRsp.CertInfo.Buffer, nil
 }
 
-func (tpm *TPM) ReadEKCert() (*x509.Certificate, error) {
-	nvIndex := tpm2.TPMHandle(EK_CERT_INDEX)
-
-	result := []byte{}
-
-	readPub := tpm2.NVReadPublic{
-		NVIndex: nvIndex,
-	}
-
-	rspRP, err := readPub.Execute(tpm.tpm)
-
-	if err != nil {
-		return nil, fmt.Errorf("read public: %w", err)
-	}
-
-	nvpubContents, err := rspRP.NVPublic.Contents()
-	if err != nil {
-		return nil, fmt.Errorf("read public: %w", err)
-	}
-
-	for i := 0; i < int(nvpubContents.DataSize); i++ {
-		read := tpm2.NVRead{
-			AuthHandle: tpm2.NamedHandle{
-				Handle: nvpubContents.NVIndex,
-				Name:   rspRP.NVName,
-			},
-			NVIndex: tpm2.NamedHandle{
-				Handle: nvpubContents.NVIndex,
-				Name:   rspRP.NVName,
-			},
-			Size:   1,
-			Offset: uint16(i),
-		}
-
-		rspNV, err := read.Execute(tpm.tpm)
-
-		if err != nil {
-			return nil, fmt.Errorf("read: %w", err)
-		}
-
-		result = append(result, rspNV.Data.Buffer...)
-	}
-
-	// TODO: rspRP.NVPublic.NVPublic.DataSize may be wrong or required to process.
-	// Because we don't know how to fix it now, we remove data based on fixed value.
-	resultSize := 0
-
-	for {
-		resultSize = len(result)
-
-		if result[resultSize-1] == 255 && resultSize != 0 {
-			result = result[:resultSize-1]
-		} else {
-			break
-		}
-	}
-
-	cert, err := x509.ParseCertificate(result)
-
-	if err != nil {
-		return nil, fmt.Errorf("parsing EK cert: %v", err)
-	}
-
-	return cert, nil
-}
-
+/**
+ * TPM2_Commit of P1 and, if P2 is given, of the point of s2 and y2.
+ * Without P2 the TPM computes only E, and L and K are nil.
+ */
 func (tpm *TPM) Commit(handle *tpm2.AuthHandle, P1_ECP *FP256BN.ECP, S2_bytes []byte, P2 *FP256BN.ECP) (*tpm2.CommitResponse, *FP256BN.ECP, *FP256BN.ECP, *FP256BN.ECP, error) {
 	/* set zero buffers to P1 */
 	xBuf := amcl_utils.BigToBytes(P1_ECP.GetX())
@@ -294,12 +281,12 @@ func (tpm *TPM) Commit(handle *tpm2.AuthHandle, P1_ECP *FP256BN.ECP, S2_bytes []
 	}
 
 	/* set up argument for commit */
-	S2 := tpm2.TPM2BSensitiveData{
-		Buffer: S2_bytes,
-	}
+	S2 := tpm2.TPM2BSensitiveData{}
+	Y2 := tpm2.TPM2BECCParameter{}
 
-	Y2 := tpm2.TPM2BECCParameter{
-		Buffer: amcl_utils.BigToBytes(P2.GetY()),
+	if P2 != nil {
+		S2.Buffer = S2_bytes
+		Y2.Buffer = amcl_utils.BigToBytes(P2.GetY())
 	}
 
 	commit := tpm2.Commit{
@@ -323,6 +310,12 @@ func (tpm *TPM) Commit(handle *tpm2.AuthHandle, P1_ECP *FP256BN.ECP, S2_bytes []
 		return nil, nil, nil, nil, fmt.Errorf("commit: %v", err)
 	}
 
+	E := parseECPFromTPMFmt(e)
+
+	if P2 == nil {
+		return rspC, E, nil, nil, nil
+	}
+
 	l, err := rspC.L.Contents()
 	if err != nil {
 		return nil, nil, nil, nil, fmt.Errorf("commit: %v", err)
@@ -333,7 +326,6 @@ func (tpm *TPM) Commit(handle *tpm2.AuthHandle, P1_ECP *FP256BN.ECP, S2_bytes []
 		return nil, nil, nil, nil, fmt.Errorf("commit: %v", err)
 	}
 
-	E := parseECPFromTPMFmt(e)
 	L := parseECPFromTPMFmt(l)
 	K := parseECPFromTPMFmt(k)
 
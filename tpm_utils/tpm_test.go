@@ -89,3 +89,30 @@ func TestActivateCredential(t *testing.T) {
 		t.Fatalf("want %x got %x", secret, result)
 	}
 }
+
+func TestSealUnseal(t *testing.T) {
+	password := []byte("hoge")
+	secret := []byte("0123456789abcdef")
+
+	tpm, err := OpenTPM(password, TPM_PATH)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer tpm.Close()
+
+	for _, pcrs := range [][]uint{{}, {0, 7}} {
+		blob, err := tpm.Seal(secret, pcrs)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		result, err := tpm.Unseal(blob)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if !bytes.Equal(result, secret) {
+			t.Fatalf("want %x got %x", secret, result)
+		}
+	}
+}
@@ -28,3 +28,60 @@ func TestEncDecCred(t *testing.T) {
 		t.Fatalf("not match: %v != %v", decC, msg)
 	}
 }
+
+func TestDERLength(t *testing.T) {
+	long := make([]byte, 4+300+8)
+	copy(long, []byte{0x30, 0x82, 0x01, 0x2c})
+	for i := 4 + 300; i < len(long); i++ {
+		long[i] = 0xff
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		length int
+		ok     bool
+	}{
+		{"short form", []byte{0x30, 0x02, 0x05, 0x00, 0xff, 0xff}, 4, true},
+		{"ends in 0xff", []byte{0x30, 0x02, 0x04, 0xff, 0xff}, 4, true},
+		{"long form with padding", long, 304, true},
+		{"empty", []byte{}, 0, false},
+		{"not sequence", []byte{0x04, 0x01, 0x00}, 0, false},
+		{"truncated", []byte{0x30, 0x82, 0x01, 0x2c, 0x00}, 0, false},
+		{"indefinite", []byte{0x30, 0x80, 0x00, 0x00}, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			length, err := DERLength(test.data)
+
+			if (err == nil) != test.ok {
+				t.Fatalf("want ok=%v got %v", test.ok, err)
+			}
+
+			if length != test.length {
+				t.Fatalf("want %v got %v", test.length, length)
+			}
+		})
+	}
+}
+
+func TestSplitDER(t *testing.T) {
+	data := []byte{0x30, 0x01, 0xff, 0x30, 0x00, 0xff, 0xff, 0xff}
+
+	elements, err := SplitDER(data)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	want := [][]byte{{0x30, 0x01, 0xff}, {0x30, 0x00}}
+
+	if !reflect.DeepEqual(elements, want) {
+		t.Fatalf("want %x got %x", want, elements)
+	}
+
+	_, err = SplitDER([]byte{0x30, 0x05, 0x00})
+	if err == nil {
+		t.Fatalf("split truncated DER")
+	}
+}
@@ -0,0 +1,291 @@
+package ecdaa
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * Traceable (group signature) mode with an opening authority.
+ *
+ * The member registers P = g1^sk with the opener at join, and each signature
+ * carries the ElGamal encryption (T1, T2) = (g1^r, P Y^r) of P under the opener
+ * key Y = g1^z with a proof that it encrypts g1^sk for the sk of the signature.
+ * The opener decrypts P and proves the decryption is correct.
+ *
+ * The proof of the encryption needs g1^a and B'^a for the same nonce a,
+ * which the TPM can not commit to, so only software member keys can sign.
+ */
+
+/**
+ * P = g1^sk of the member and the proof that sk is the same as of Q = B^sk.
+ */
+type TracingKey struct {
+	P *FP256BN.ECP
+	C *FP256BN.BIG
+	S *FP256BN.BIG
+}
+
+func tracingKeyHash(B, P, Q, R1, R2 *FP256BN.ECP) *FP256BN.BIG {
+	hash := amcl_utils.NewHash()
+	hash.WriteECP(amcl_utils.G1(), B, P, Q, R1, R2)
+
+	return hash.SumToBIG()
+}
+
+/**
+ * Make the tracing key of the member key for the join of the seed point B.
+ */
+func GenTracingKey(sk *FP256BN.BIG, B *FP256BN.ECP, rng *core.RAND) *TracingKey {
+	a := amcl_utils.RandomBig(rng)
+
+	P := amcl_utils.G1().Mul(sk)
+	Q := B.Mul(sk)
+
+	c := tracingKeyHash(B, P, Q, amcl_utils.G1().Mul(a), B.Mul(a))
+
+	return &TracingKey{
+		P: P,
+		C: c,
+		S: schnorrResponse(a, c, sk),
+	}
+}
+
+/**
+ * Verify the tracing key belongs to the join request of Q on B.
+ */
+func (key *TracingKey) Verify(B, Q *FP256BN.ECP) error {
+	R1 := schnorrCommitment(amcl_utils.G1(), key.S, key.P, key.C)
+	R2 := schnorrCommitment(B, key.S, Q, key.C)
+
+	c := tracingKeyHash(B, key.P, Q, R1, R2)
+
+	if FP256BN.Comp(key.C, c) != 0 {
+		return fmt.Errorf("tracing key is not for the member key")
+	}
+
+	return nil
+}
+
+/**
+ * Signature with the encrypted tracing key of the signer.
+ */
+type TraceableSignature struct {
+	Signature *Signature
+	T1        *FP256BN.ECP
+	T2        *FP256BN.ECP
+	C         *FP256BN.BIG
+	SSK       *FP256BN.BIG
+	SR        *FP256BN.BIG
+}
+
+func traceableHash(message, basename []byte, signature *TraceableSignature, Y, R1, R2, R3 *FP256BN.ECP) *FP256BN.BIG {
+	cred := signature.Signature.RandomizedCred
+
+	hash := amcl_utils.NewHash()
+	hash.WriteECP(amcl_utils.G1(), Y, cred.B, cred.D, signature.T1, signature.T2, R1, R2, R3)
+	hash.WriteBIG(signature.Signature.Proof.SmallC)
+	hash.WriteBytes(basename, message)
+
+	return hash.SumToBIG()
+}
+
+type TraceableSigner struct {
+	signer SWSigner
+	opener *FP256BN.ECP
+}
+
+/**
+ * opener is the public key of the opener.
+ */
+func NewTraceableSigner(signer SWSigner, opener *FP256BN.ECP) TraceableSigner {
+	var traceableSigner = TraceableSigner{
+		signer: signer,
+		opener: opener,
+	}
+
+	return traceableSigner
+}
+
+func (signer TraceableSigner) Sign(message, basename []byte, rng *core.RAND) (*TraceableSignature, error) {
+	signature, err := signer.signer.Sign(message, basename, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	sk := signer.signer.sk
+	Y := signer.opener
+
+	// (T1, T2) = (g1^r, g1^sk Y^r)
+	r := amcl_utils.RandomBig(rng)
+
+	T2 := amcl_utils.G1().Mul(sk)
+	T2.Add(Y.Mul(r))
+
+	traceable := TraceableSignature{
+		Signature: signature,
+		T1:        amcl_utils.G1().Mul(r),
+		T2:        T2,
+	}
+
+	// R1 = g1^b, R2 = g1^a Y^b, R3 = B'^a
+	a := amcl_utils.RandomBig(rng)
+	b := amcl_utils.RandomBig(rng)
+
+	R2 := amcl_utils.G1().Mul(a)
+	R2.Add(Y.Mul(b))
+
+	R1 := amcl_utils.G1().Mul(b)
+	R3 := signature.RandomizedCred.B.Mul(a)
+
+	traceable.C = traceableHash(message, basename, &traceable, Y, R1, R2, R3)
+	traceable.SSK = schnorrResponse(a, traceable.C, sk)
+	traceable.SR = schnorrResponse(b, traceable.C, r)
+
+	return &traceable, nil
+}
+
+func verifyEncryption(message, basename []byte, signature *TraceableSignature, opener *FP256BN.ECP) error {
+	cred := signature.Signature.RandomizedCred
+
+	// R1 = g1^sr T1^-c
+	R1 := schnorrCommitment(amcl_utils.G1(), signature.SR, signature.T1, signature.C)
+
+	// R2 = g1^ssk Y^sr T2^-c
+	R2 := amcl_utils.G1().Mul(signature.SSK)
+	R2.Add(opener.Mul(signature.SR))
+	R2.Sub(signature.T2.Mul(signature.C))
+
+	// R3 = B'^ssk D'^-c
+	R3 := schnorrCommitment(cred.B, signature.SSK, cred.D, signature.C)
+
+	c := traceableHash(message, basename, signature, opener, R1, R2, R3)
+
+	if FP256BN.Comp(signature.C, c) != 0 {
+		return fmt.Errorf("tracing key is not encrypted correctly")
+	}
+
+	return nil
+}
+
+/**
+ * Verify the signature and the encryption of the tracing key to the opener.
+ */
+func VerifyTraceable(message, basename []byte, signature *TraceableSignature, ipk *IPK, opener *FP256BN.ECP, rl RevocationList) error {
+	err := Verify(message, basename, signature.Signature, ipk, rl)
+	if err != nil {
+		return err
+	}
+
+	return verifyEncryption(message, basename, signature, opener)
+}
+
+/**
+ * Result of opening: the identity of the join record of the tracing key P,
+ * and the proof that the signature decrypts to P under the opener key.
+ */
+type Opening struct {
+	Identity []byte
+	P        *FP256BN.ECP
+	C        *FP256BN.BIG
+	S        *FP256BN.BIG
+}
+
+func openingHash(Y, T1, T2, P, R1, R2 *FP256BN.ECP) *FP256BN.BIG {
+	hash := amcl_utils.NewHash()
+	hash.WriteECP(amcl_utils.G1(), Y, T1, T2, P, R1, R2)
+
+	return hash.SumToBIG()
+}
+
+/**
+ * Opening authority which holds the decryption key z and
+ * the identities of the registered tracing keys.
+ */
+type Opener struct {
+	z          *FP256BN.BIG
+	Y          *FP256BN.ECP
+	identities map[string][]byte
+	mu         sync.RWMutex
+}
+
+func RandomOpener(rng *core.RAND) *Opener {
+	z := amcl_utils.RandomBig(rng)
+
+	return &Opener{
+		z:          z,
+		Y:          amcl_utils.G1().Mul(z),
+		identities: map[string][]byte{},
+	}
+}
+
+/**
+ * Register the tracing key of the join of Q on B with the identity
+ * of the join record.
+ */
+func (opener *Opener) Register(identity []byte, key *TracingKey, B, Q *FP256BN.ECP) error {
+	err := key.Verify(B, Q)
+	if err != nil {
+		return err
+	}
+
+	opener.mu.Lock()
+	defer opener.mu.Unlock()
+
+	opener.identities[string(amcl_utils.EcpToBytes(key.P))] = identity
+
+	return nil
+}
+
+/**
+ * Decrypt the tracing key of the signature, which must already be verified,
+ * and prove log_g1 Y = log_T1 (T2 / P).
+ */
+func (opener *Opener) Open(signature *TraceableSignature, rng *core.RAND) (*Opening, error) {
+	P := FP256BN.NewECP()
+	P.Copy(signature.T2)
+	P.Sub(signature.T1.Mul(opener.z))
+
+	opener.mu.RLock()
+	identity, ok := opener.identities[string(amcl_utils.EcpToBytes(P))]
+	opener.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("tracing key is not registered")
+	}
+
+	a := amcl_utils.RandomBig(rng)
+	c := openingHash(opener.Y, signature.T1, signature.T2, P, amcl_utils.G1().Mul(a), signature.T1.Mul(a))
+
+	return &Opening{
+		Identity: identity,
+		P:        P,
+		C:        c,
+		S:        schnorrResponse(a, c, opener.z),
+	}, nil
+}
+
+/**
+ * Verify the signature is opened to P correctly by the opener.
+ */
+func VerifyOpening(signature *TraceableSignature, opener *FP256BN.ECP, opening *Opening) error {
+	// T2 / P = T1^z
+	TP := FP256BN.NewECP()
+	TP.Copy(signature.T2)
+	TP.Sub(opening.P)
+
+	R1 := schnorrCommitment(amcl_utils.G1(), opening.S, opener, opening.C)
+	R2 := schnorrCommitment(signature.T1, opening.S, TP, opening.C)
+
+	c := openingHash(opener, signature.T1, signature.T2, opening.P, R1, R2)
+
+	if FP256BN.Comp(opening.C, c) != 0 {
+		return fmt.Errorf("opening is not valid")
+	}
+
+	return nil
+}
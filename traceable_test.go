@@ -0,0 +1,128 @@
+package ecdaa
+
+import (
+	"testing"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestTraceable(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	issuer, sw, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	other, err := ExampleJoin(issuer, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	opener := RandomOpener(rng)
+
+	key := GenTracingKey(sw.sk, sw.cred.B, rng)
+
+	encoded, err := key.Encode()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var decodedKey TracingKey
+
+	err = decodedKey.Decode(encoded)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	err = opener.Register([]byte("member"), &decodedKey, sw.cred.B, sw.cred.D)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if opener.Register([]byte("other"), key, other.cred.B, other.cred.D) == nil {
+		t.Fatalf("tracing key of other member is registered")
+	}
+
+	signer := NewTraceableSigner(*sw, opener.Y)
+
+	for _, bsn := range [][]byte{basename, nil} {
+		name := "linkable"
+		if bsn == nil {
+			name = "unlinkable"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			signature, err := signer.Sign(message, bsn, rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			encoded, err := signature.Encode()
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			var decoded TraceableSignature
+
+			err = decoded.Decode(encoded)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			err = VerifyTraceable(message, bsn, &decoded, &issuer.Ipk, opener.Y, RevocationList{})
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			opening, err := opener.Open(&decoded, rng)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			if string(opening.Identity) != "member" {
+				t.Fatalf("opened to %s", opening.Identity)
+			}
+
+			err = VerifyOpening(&decoded, opener.Y, opening)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			opening.P = other.cred.D
+			if VerifyOpening(&decoded, opener.Y, opening) == nil {
+				t.Fatalf("wrong opening is verified")
+			}
+		})
+	}
+
+	t.Run("wrong_encryption", func(t *testing.T) {
+		signature, err := signer.Sign(message, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		// the encryption is taken from the signature of other member
+		otherSignature, err := other.Sign(message, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		signature.Signature = otherSignature
+
+		if VerifyTraceable(message, basename, signature, &issuer.Ipk, opener.Y, RevocationList{}) == nil {
+			t.Fatalf("encryption of other member is verified")
+		}
+
+		signature, err = signer.Sign(message, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if VerifyTraceable(message, basename, signature, &issuer.Ipk, RandomOpener(rng).Y, RevocationList{}) == nil {
+			t.Fatalf("encryption to other opener is verified")
+		}
+	})
+}
@@ -0,0 +1,277 @@
+package ecdaa
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+const (
+	// files of the encoded IPK, named by the group
+	TrustIPKExt = ".ipk"
+	// files of the encoded TrustedIPK
+	TrustEntryExt = ".trust"
+)
+
+/**
+ * Canonical encoding of the IPK: X || Y || C || SX || SY in fixed sizes.
+ */
+func (ipk *IPK) CanonicalBytes() []byte {
+	buf := []byte{}
+	buf = append(buf, amcl_utils.Ecp2ToBytes(ipk.X)...)
+	buf = append(buf, amcl_utils.Ecp2ToBytes(ipk.Y)...)
+	buf = append(buf, amcl_utils.BigToBytes(ipk.C)...)
+	buf = append(buf, amcl_utils.BigToBytes(ipk.SX)...)
+	buf = append(buf, amcl_utils.BigToBytes(ipk.SY)...)
+
+	return buf
+}
+
+/**
+ * Fingerprint of the IPK: SHA-256 of the canonical encoding.
+ */
+func IPKFingerprint(ipk *IPK) []byte {
+	hash := sha256.Sum256(ipk.CanonicalBytes())
+
+	return hash[:]
+}
+
+/**
+ * Signer which tags the signatures with the fingerprint of the IPK,
+ * so the verifiers can pick the IPK from their trust stores.
+ *
+ * The fingerprint is not covered by the proof: a wrong one only makes
+ * the verification fail with the IPK it points to.
+ */
+type FingerprintSigner struct {
+	signer      Signer
+	fingerprint []byte
+}
+
+func NewFingerprintSigner(signer Signer, ipk *IPK) FingerprintSigner {
+	var fingerprintSigner = FingerprintSigner{
+		signer:      signer,
+		fingerprint: IPKFingerprint(ipk),
+	}
+
+	return fingerprintSigner
+}
+
+func (signer FingerprintSigner) Sign(message, basename []byte, rng *core.RAND) (*Signature, error) {
+	signature, err := signer.signer.Sign(message, basename, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	signature.Fingerprint = signer.fingerprint
+
+	return signature, nil
+}
+
+/**
+ * IPK pinned by the verifier with its metadata.
+ *
+ * The zero NotBefore and NotAfter are unbounded. If Basenames is empty,
+ * any basename (and the unlinkable signatures) is allowed.
+ */
+type TrustedIPK struct {
+	IPK       *IPK
+	Group     string
+	NotBefore time.Time
+	NotAfter  time.Time
+	Basenames [][]byte
+}
+
+func (entry *TrustedIPK) ValidAt(now time.Time) bool {
+	if !entry.NotBefore.IsZero() && now.Before(entry.NotBefore) {
+		return false
+	}
+
+	return entry.NotAfter.IsZero() || now.Before(entry.NotAfter)
+}
+
+func (entry *TrustedIPK) AllowsBasename(basename []byte) bool {
+	if len(entry.Basenames) == 0 {
+		return true
+	}
+
+	for _, allowed := range entry.Basenames {
+		if basename != nil && string(allowed) == string(basename) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * Store of the trusted IPKs by their fingerprints.
+ */
+type TrustStore struct {
+	entries map[string]*TrustedIPK
+	mu      sync.RWMutex
+}
+
+func NewTrustStore() *TrustStore {
+	return &TrustStore{
+		entries: map[string]*TrustedIPK{},
+	}
+}
+
+/**
+ * Load the files in the directory: encoded IPKs (*.ipk) trusted as the group
+ * of the file name without limits, and encoded TrustedIPKs (*.trust).
+ * The other files are ignored.
+ */
+func LoadTrustStore(dir string) (*TrustStore, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	store := NewTrustStore()
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		name := file.Name()
+		ext := filepath.Ext(name)
+
+		if ext != TrustIPKExt && ext != TrustEntryExt {
+			continue
+		}
+
+		encoded, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		var entry TrustedIPK
+
+		if ext == TrustIPKExt {
+			var ipk IPK
+
+			err = ipk.Decode(encoded)
+
+			entry.IPK = &ipk
+			entry.Group = strings.TrimSuffix(name, ext)
+		} else {
+			err = entry.Decode(encoded)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+
+		_, err = store.Add(&entry)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+	}
+
+	return store, nil
+}
+
+/**
+ * Add the entry after verifying the IPK, and return its fingerprint.
+ */
+func (store *TrustStore) Add(entry *TrustedIPK) ([]byte, error) {
+	err := VerifyIPK(entry.IPK)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := IPKFingerprint(entry.IPK)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.entries[string(fingerprint)] = entry
+
+	return fingerprint, nil
+}
+
+func (store *TrustStore) Remove(fingerprint []byte) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.entries, string(fingerprint))
+}
+
+/**
+ * Fingerprints of the entries in sorted order.
+ */
+func (store *TrustStore) Fingerprints() [][]byte {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	keys := []string{}
+	for key := range store.entries {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	fingerprints := [][]byte{}
+	for _, key := range keys {
+		fingerprints = append(fingerprints, []byte(key))
+	}
+
+	return fingerprints
+}
+
+/**
+ * Find the entry by the fingerprint and check it is valid at the time.
+ */
+func (store *TrustStore) Lookup(fingerprint []byte, now time.Time) (*TrustedIPK, error) {
+	store.mu.RLock()
+	entry, ok := store.entries[string(fingerprint)]
+	store.mu.RUnlock()
+
+	if !ok {
+		return nil, verifyError(ErrIPKUntrusted, "fingerprint %v is not found", hex.EncodeToString(fingerprint))
+	}
+
+	if !entry.ValidAt(now) {
+		return nil, verifyError(ErrIPKUntrusted, "IPK of %v is not valid at %v", entry.Group, now)
+	}
+
+	return entry, nil
+}
+
+/**
+ * Verify the signature with the trusted IPK of the fingerprint carried in it,
+ * and return the entry to tell the group of the signer.
+ */
+func VerifyWithTrustStore(message, basename []byte, signature *Signature, store *TrustStore, now time.Time, rl RevocationList) (*TrustedIPK, error) {
+	if signature == nil || signature.Fingerprint == nil {
+		return nil, verifyError(ErrMalformed, "signature has no fingerprint")
+	}
+
+	entry, err := store.Lookup(signature.Fingerprint, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entry.AllowsBasename(basename) {
+		return nil, verifyError(ErrBasenameMismatch, "basename is not allowed in %v", entry.Group)
+	}
+
+	err = Verify(message, basename, signature, entry.IPK, rl)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
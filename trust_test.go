@@ -0,0 +1,186 @@
+package ecdaa
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+func TestTrustStore(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	now := time.Now()
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	fleet, fleetSigner, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	vendor, vendorSigner, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expired, expiredSigner, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if string(IPKFingerprint(&fleet.Ipk)) == string(IPKFingerprint(&vendor.Ipk)) {
+		t.Fatalf("fingerprints of the IPKs are same")
+	}
+
+	encoded, err := fleet.Ipk.Encode()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var decoded IPK
+
+	err = decoded.Decode(encoded)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if string(IPKFingerprint(&decoded)) != string(IPKFingerprint(&fleet.Ipk)) {
+		t.Fatalf("fingerprint is changed by encoding")
+	}
+
+	// the fleet as a plain IPK, the others with the metadata
+	dir := t.TempDir()
+
+	err = os.WriteFile(filepath.Join(dir, "fleet"+TrustIPKExt), encoded, 0600)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	entries := map[string]*TrustedIPK{
+		"vendor": {
+			IPK:       &vendor.Ipk,
+			Group:     "vendor",
+			Basenames: [][]byte{basename},
+		},
+		"expired": {
+			IPK:      &expired.Ipk,
+			Group:    "expired",
+			NotAfter: now.Add(-time.Hour),
+		},
+	}
+
+	for name, entry := range entries {
+		encoded, err := entry.Encode()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = os.WriteFile(filepath.Join(dir, name+TrustEntryExt), encoded, 0600)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0600)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	store, err := LoadTrustStore(dir)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if len(store.Fingerprints()) != 3 {
+		t.Fatalf("store has %v entries", len(store.Fingerprints()))
+	}
+
+	sign := func(signer *SWSigner, ipk *IPK, bsn []byte) *Signature {
+		signature, err := NewFingerprintSigner(signer, ipk).Sign(message, bsn, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		encoded, err := signature.Encode()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var decoded Signature
+
+		err = decoded.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		return &decoded
+	}
+
+	t.Run("groups", func(t *testing.T) {
+		for group, signature := range map[string]*Signature{
+			"fleet":  sign(fleetSigner, &fleet.Ipk, nil),
+			"vendor": sign(vendorSigner, &vendor.Ipk, basename),
+		} {
+			bsn := basename
+			if group == "fleet" {
+				bsn = nil
+			}
+
+			entry, err := VerifyWithTrustStore(message, bsn, signature, store, now, RevocationList{})
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			if entry.Group != group {
+				t.Fatalf("signature of %v is verified as %v", group, entry.Group)
+			}
+		}
+	})
+
+	t.Run("basename", func(t *testing.T) {
+		signature := sign(vendorSigner, &vendor.Ipk, nil)
+
+		_, err := VerifyWithTrustStore(message, nil, signature, store, now, RevocationList{})
+		if !errors.Is(err, ErrBasenameMismatch) {
+			t.Fatalf("unlinkable signature is not refused: %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		signature := sign(expiredSigner, &expired.Ipk, basename)
+
+		_, err := VerifyWithTrustStore(message, basename, signature, store, now, RevocationList{})
+		if !errors.Is(err, ErrIPKUntrusted) {
+			t.Fatalf("expired IPK is not refused: %v", err)
+		}
+	})
+
+	t.Run("fingerprint", func(t *testing.T) {
+		signature := sign(fleetSigner, &fleet.Ipk, basename)
+		signature.Fingerprint = IPKFingerprint(&vendor.Ipk)
+
+		_, err := VerifyWithTrustStore(message, basename, signature, store, now, RevocationList{})
+		if !errors.Is(err, ErrCredentialInvalid) {
+			t.Fatalf("signature is verified with other IPK: %v", err)
+		}
+
+		signature.Fingerprint = nil
+
+		_, err = VerifyWithTrustStore(message, basename, signature, store, now, RevocationList{})
+		if !errors.Is(err, ErrMalformed) {
+			t.Fatalf("signature without fingerprint is not refused: %v", err)
+		}
+
+		store.Remove(IPKFingerprint(&fleet.Ipk))
+
+		signature.Fingerprint = IPKFingerprint(&fleet.Ipk)
+
+		_, err = VerifyWithTrustStore(message, basename, signature, store, now, RevocationList{})
+		if !errors.Is(err, ErrIPKUntrusted) {
+			t.Fatalf("removed IPK is trusted: %v", err)
+		}
+	})
+}
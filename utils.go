@@ -0,0 +1,97 @@
+package ecdaa
+
+import (
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+)
+
+/**
+ * a - b mod p
+ */
+func modSub(a, b *FP256BN.BIG) *FP256BN.BIG {
+	neg := FP256BN.Modneg(b, amcl_utils.P())
+	return FP256BN.Modadd(a, neg, amcl_utils.P())
+}
+
+/**
+ * r + c * w mod p (response of Schnorr proofs)
+ */
+func schnorrResponse(r, c, w *FP256BN.BIG) *FP256BN.BIG {
+	s := FP256BN.Modmul(c, w, amcl_utils.P())
+	return FP256BN.Modadd(r, s, amcl_utils.P())
+}
+
+/**
+ * Overwrite the secret with 0 in place.
+ *
+ * FP256BN.BIG has no exported setter to zero, so reduce it modulo 1:
+ * Mod works in place with the constant-time reduction, and writes every
+ * limb of x with the remainder, which is always 0.
+ */
+func zeroizeBIG(x *FP256BN.BIG) {
+	x.Mod(FP256BN.NewBIGint(1))
+}
+
+/**
+ * X^s Y^-c (commitment of Schnorr proofs recomputed from the response)
+ */
+func schnorrCommitment(X *FP256BN.ECP, s *FP256BN.BIG, Y *FP256BN.ECP, c *FP256BN.BIG) *FP256BN.ECP {
+	R := X.Mul(s)
+	R.Sub(Y.Mul(c))
+
+	return R
+}
+
+/**
+ * -P
+ */
+func negECP(p *FP256BN.ECP) *FP256BN.ECP {
+	neg := FP256BN.NewECP()
+	neg.Copy(p)
+	neg.Neg()
+
+	return neg
+}
+
+/**
+ * Σ points[i]^scalars[i]
+ */
+func multiExp(points []*FP256BN.ECP, scalars []*FP256BN.BIG) *FP256BN.ECP {
+	result := FP256BN.NewECP()
+
+	for i := range points {
+		result.Add(points[i].Mul(scalars[i]))
+	}
+
+	return result
+}
+
+/**
+ * Generator of G1 which nobody knows the discrete log of,
+ * derived from the label with hash-to-curve.
+ */
+func generatorFromLabel(label []byte) (*FP256BN.ECP, error) {
+	hash := amcl_utils.NewHash()
+	hash.WriteBytes(label)
+
+	P, _, err := hash.HashToECP()
+
+	return P, err
+}
+
+/**
+ * B_bsn of the pseudonym K = B_bsn^sk on the basename.
+ */
+func basenamePoint(basename []byte) (*FP256BN.ECP, error) {
+	return generatorFromLabel(basename)
+}
+
+/**
+ * Ate(P1, Q1) == Ate(P2, Q2) after final exponentiation.
+ */
+func pairingEquals(P1 *FP256BN.ECP2, Q1 *FP256BN.ECP, P2 *FP256BN.ECP2, Q2 *FP256BN.ECP) bool {
+	a := FP256BN.Fexp(FP256BN.Ate(P1, Q1))
+	b := FP256BN.Fexp(FP256BN.Ate(P2, Q2))
+
+	return a.Equals(b)
+}
@@ -0,0 +1,189 @@
+package ecdaa
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+/**
+ * Identifier of the IPK: the fingerprint in hex.
+ */
+func IPKID(ipk *IPK) string {
+	return hex.EncodeToString(IPKFingerprint(ipk))
+}
+
+/**
+ * Member key which the credentials in the wallet are issued to.
+ */
+type MemberKey interface {
+	Signer(cred *Credential) Signer
+}
+
+type SWKey struct {
+	SK *FP256BN.BIG
+}
+
+func (key *SWKey) Signer(cred *Credential) Signer {
+	return NewSWSigner(cred, key.SK)
+}
+
+type TPMKey struct {
+	Handles *KeyHandles
+	TPM     *tpm_utils.TPM
+}
+
+func (key *TPMKey) Signer(cred *Credential) Signer {
+	signer := NewTPMSigner(cred, key.Handles, key.TPM)
+	return &signer
+}
+
+type SealedKey struct {
+	Blob *tpm_utils.SealedBlob
+	TPM  *tpm_utils.TPM
+}
+
+func (key *SealedKey) Signer(cred *Credential) Signer {
+	return NewSealedSigner(cred, key.Blob, key.TPM)
+}
+
+/**
+ * Credential in the wallet, issued under the IPK to the key named Key.
+ */
+type WalletCredential struct {
+	IPK   *IPK
+	Cred  *Credential
+	Epoch uint32
+	Key   string
+}
+
+/**
+ * Member-side store of the credentials of the groups the device belongs to.
+ *
+ * The keys are added by name, and a key (e.g. a TPM key) can have
+ * the credentials of many issuers. Encode persists only the credentials,
+ * so the keys must be added again before Decode, which refuses the
+ * credentials of unknown keys.
+ */
+type Wallet struct {
+	keys        map[string]MemberKey
+	credentials map[string]*WalletCredential
+	mu          sync.RWMutex
+}
+
+func NewWallet() *Wallet {
+	return &Wallet{
+		keys:        map[string]MemberKey{},
+		credentials: map[string]*WalletCredential{},
+	}
+}
+
+func (wallet *Wallet) AddKey(name string, key MemberKey) {
+	wallet.mu.Lock()
+	defer wallet.mu.Unlock()
+
+	wallet.keys[name] = key
+}
+
+/**
+ * Add the credential of the key, and return the ID of the IPK to sign with it.
+ * The credential of the same IPK is replaced (e.g. re-issued).
+ */
+func (wallet *Wallet) Add(ipk *IPK, cred *Credential, epoch uint32, key string) (string, error) {
+	err := VerifyCred(cred, ipk)
+	if err != nil {
+		return "", err
+	}
+
+	wallet.mu.Lock()
+	defer wallet.mu.Unlock()
+
+	if _, ok := wallet.keys[key]; !ok {
+		return "", fmt.Errorf("key %v is not found", key)
+	}
+
+	id := IPKID(ipk)
+
+	wallet.credentials[id] = &WalletCredential{
+		IPK:   ipk,
+		Cred:  cred,
+		Epoch: epoch,
+		Key:   key,
+	}
+
+	return id, nil
+}
+
+func (wallet *Wallet) Remove(id string) {
+	wallet.mu.Lock()
+	defer wallet.mu.Unlock()
+
+	delete(wallet.credentials, id)
+}
+
+/**
+ * IDs of the IPKs of the credentials in sorted order.
+ */
+func (wallet *Wallet) IDs() []string {
+	wallet.mu.RLock()
+	defer wallet.mu.RUnlock()
+
+	ids := []string{}
+	for id := range wallet.credentials {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+func (wallet *Wallet) Credential(id string) (*WalletCredential, error) {
+	wallet.mu.RLock()
+	defer wallet.mu.RUnlock()
+
+	credential, ok := wallet.credentials[id]
+	if !ok {
+		return nil, fmt.Errorf("credential of IPK %v is not found", id)
+	}
+
+	return credential, nil
+}
+
+/**
+ * Signer with the credential of the IPK, tagging the signatures with
+ * its epoch and the fingerprint of the IPK.
+ */
+func (wallet *Wallet) Signer(id string) (Signer, error) {
+	credential, err := wallet.Credential(id)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet.mu.RLock()
+	key, ok := wallet.keys[credential.Key]
+	wallet.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("key %v is not found", credential.Key)
+	}
+
+	signer := NewEpochSigner(key.Signer(credential.Cred), credential.Epoch)
+
+	return NewFingerprintSigner(signer, credential.IPK), nil
+}
+
+func (wallet *Wallet) Sign(id string, message, basename []byte, rng *core.RAND) (*Signature, error) {
+	signer, err := wallet.Signer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer.Sign(message, basename, rng)
+}
@@ -0,0 +1,218 @@
+package ecdaa
+
+import (
+	"testing"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+func walletJoin(t *testing.T, issuer *Issuer, sk *FP256BN.BIG, rng *core.RAND) *Credential {
+	seed, B, err := GenJoinSeed(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	req := GenJoinReqWithKey(seed, sk, rng)
+
+	err = VerifyJoinReq(req, seed, B)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	cred, err := issuer.MakeCred(req, B, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return cred
+}
+
+func TestWallet(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	issuers := []Issuer{RandomIssuer(rng), RandomIssuer(rng), RandomIssuer(rng)}
+
+	fleetKey := SWKey{SK: amcl_utils.RandomBig(rng)}
+	vendorKey := SWKey{SK: amcl_utils.RandomBig(rng)}
+
+	wallet := NewWallet()
+	wallet.AddKey("fleet", &fleetKey)
+	wallet.AddKey("vendor", &vendorKey)
+
+	ids := []string{}
+
+	// the first key is in two groups
+	for i, key := range []string{"fleet", "fleet", "vendor"} {
+		sk := fleetKey.SK
+		if key == "vendor" {
+			sk = vendorKey.SK
+		}
+
+		cred := walletJoin(t, &issuers[i], sk, rng)
+
+		id, err := wallet.Add(&issuers[i].Ipk, cred, uint32(i), key)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if id != IPKID(&issuers[i].Ipk) {
+			t.Fatalf("ID is not of the IPK")
+		}
+
+		ids = append(ids, id)
+	}
+
+	if len(wallet.IDs()) != 3 {
+		t.Fatalf("wallet has %v credentials", len(wallet.IDs()))
+	}
+
+	_, err := wallet.Add(&issuers[1].Ipk, walletJoin(t, &issuers[0], fleetKey.SK, rng), 0, "fleet")
+	if err == nil {
+		t.Fatalf("credential of other IPK is added")
+	}
+
+	encoded, err := wallet.Encode()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if (&Wallet{}).Decode(encoded) == nil {
+		t.Fatalf("credentials are decoded without the keys")
+	}
+
+	decoded := NewWallet()
+	decoded.AddKey("fleet", &fleetKey)
+	decoded.AddKey("vendor", &vendorKey)
+
+	err = decoded.Decode(encoded)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	for i, id := range ids {
+		signature, err := decoded.Sign(id, message, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if signature.Epoch != uint32(i) {
+			t.Fatalf("signature is tagged with epoch %v", signature.Epoch)
+		}
+
+		if string(signature.Fingerprint) != string(IPKFingerprint(&issuers[i].Ipk)) {
+			t.Fatalf("signature is not tagged with the fingerprint")
+		}
+
+		err = Verify(message, basename, signature, &issuers[i].Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		other := &issuers[(i+1)%len(issuers)].Ipk
+
+		if Verify(message, basename, signature, other, RevocationList{}) == nil {
+			t.Fatalf("signature is verified with other IPK")
+		}
+	}
+
+	decoded.Remove(ids[0])
+
+	_, err = decoded.Sign(ids[0], message, basename, rng)
+	if err == nil {
+		t.Fatalf("removed credential signs")
+	}
+}
+
+func TestWalletTPM(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	password := []byte("piyo")
+	message := []byte("hoge")
+	basename := []byte("fuga")
+
+	tpm, err := tpm_utils.OpenTPM(password, tpm_utils.TPM_PATH)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer tpm.Close()
+
+	issuers := []Issuer{RandomIssuer(rng), RandomIssuer(rng), RandomIssuer(rng)}
+
+	// the TPM key joins the first two groups
+	var handles *KeyHandles
+	creds := []*Credential{}
+
+	for i := 0; i < 2; i++ {
+		seed, B, err := GenJoinSeed(rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var req *JoinRequestTPM
+
+		if handles == nil {
+			req, handles, err = GenJoinReqWithTPM(seed, tpm, rng)
+		} else {
+			req, err = GenJoinReqWithTPMKey(seed, tpm, handles, rng)
+		}
+
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		cipher, _, err := issuers[i].MakeCredEncrypted(req, B, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		cred, err := ActivateCredential(cipher, B, req.JoinReq.Q, &issuers[i].Ipk, handles, tpm)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		creds = append(creds, cred)
+	}
+
+	seed, B, err := GenJoinSeed(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	req, blob, err := GenJoinReqSealed(seed, tpm, []uint{}, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	sealedCred, err := issuers[2].MakeCred(req, B, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	creds = append(creds, sealedCred)
+
+	wallet := NewWallet()
+	wallet.AddKey("tpm", &TPMKey{Handles: handles, TPM: tpm})
+	wallet.AddKey("sealed", &SealedKey{Blob: blob, TPM: tpm})
+
+	for i, key := range []string{"tpm", "tpm", "sealed"} {
+		id, err := wallet.Add(&issuers[i].Ipk, creds[i], 0, key)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		signature, err := wallet.Sign(id, message, basename, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = Verify(message, basename, signature, &issuers[i].Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+}
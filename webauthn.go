@@ -0,0 +1,356 @@
+package ecdaa
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/akakou-fork/amcl-go/miracl/core"
+	"github.com/akakou-fork/amcl-go/miracl/core/FP256BN"
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+	"github.com/fxamacker/cbor/v2"
+)
+
+/**
+ * CBOR/COSE encodings and WebAuthn "packed" attestation of ECDAA type.
+ *
+ * The attestation statement is {alg, sig, ecdaaKeyId} over
+ * authenticatorData || clientDataHash, signed without basename so the
+ * attestations of an authenticator are unlinkable. The sig is the CBOR
+ * encoding of the Signature of this scheme (with the nonce n), not the
+ * FIDO ECDAA signature, so the alg is not ED256 but of private use, and
+ * the relying party verifies it with this library.
+ * It carries no pseudonym K, which would be the same for all the
+ * attestations of the key, and the attestations with K are refused.
+ */
+
+const (
+	// ECDAA of this scheme on FP256BN with SHA-256, in private use
+	// since the signature is not of ED256 (-260) of WebAuthn Level 1
+	COSEAlgECDAA = -65538
+
+	// no key type is registered for ECDAA issuer keys, so it is in private use
+	COSEKtyECDAA = -65537
+
+	AttestationFormatPacked = "packed"
+)
+
+func encodeCBOR(data any) ([]byte, error) {
+	mode, err := cbor.CTAP2EncOptions().EncMode()
+	if err != nil {
+		return nil, err
+	}
+
+	return mode.Marshal(data)
+}
+
+func decodeCBOR(encoded []byte, target any) error {
+	mode, err := cbor.DecOptions{DupMapKey: cbor.DupMapKeyEnforcedAPF}.DecMode()
+	if err != nil {
+		return err
+	}
+
+	return mode.Unmarshal(encoded, target)
+}
+
+/**
+ * ecdaaKeyId of the IPK: BigNumberToB(c) as in WebAuthn.
+ */
+func ECDAAKeyID(ipk *IPK) []byte {
+	return amcl_utils.BigToBytes(ipk.C)
+}
+
+type MiddleCOSEIPK struct {
+	Kty int    `cbor:"1,keyasint"`
+	Alg int    `cbor:"3,keyasint"`
+	X   []byte `cbor:"-1,keyasint"`
+	Y   []byte `cbor:"-2,keyasint"`
+	C   []byte `cbor:"-3,keyasint"`
+	SX  []byte `cbor:"-4,keyasint"`
+	SY  []byte `cbor:"-5,keyasint"`
+}
+
+func (ipk *IPK) EncodeCOSE() ([]byte, error) {
+	return encodeCBOR(MiddleCOSEIPK{
+		Kty: COSEKtyECDAA,
+		Alg: COSEAlgECDAA,
+		X:   amcl_utils.Ecp2ToBytes(ipk.X),
+		Y:   amcl_utils.Ecp2ToBytes(ipk.Y),
+		C:   amcl_utils.BigToBytes(ipk.C),
+		SX:  amcl_utils.BigToBytes(ipk.SX),
+		SY:  amcl_utils.BigToBytes(ipk.SY),
+	})
+}
+
+func (decoded *IPK) DecodeCOSE(encoded []byte) error {
+	var mid MiddleCOSEIPK
+
+	err := decodeCBOR(encoded, &mid)
+	if err != nil {
+		return err
+	}
+
+	if mid.Kty != COSEKtyECDAA || mid.Alg != COSEAlgECDAA {
+		return verifyError(ErrMalformed, "COSE key is not of ECDAA: kty %v, alg %v", mid.Kty, mid.Alg)
+	}
+
+	X, err := jsonToECP2("x", mid.X)
+	if err != nil {
+		return err
+	}
+
+	Y, err := jsonToECP2("y", mid.Y)
+	if err != nil {
+		return err
+	}
+
+	scalars := []*FP256BN.BIG{}
+
+	for _, field := range []struct {
+		name string
+		buf  []byte
+	}{{"c", mid.C}, {"sx", mid.SX}, {"sy", mid.SY}} {
+		x, err := jsonToBIG(field.name, field.buf)
+		if err != nil {
+			return err
+		}
+
+		scalars = append(scalars, x)
+	}
+
+	decoded.X = X
+	decoded.Y = Y
+	decoded.C = scalars[0]
+	decoded.SX = scalars[1]
+	decoded.SY = scalars[2]
+
+	return nil
+}
+
+type MiddleCBORSignature struct {
+	SmallC      []byte `cbor:"1,keyasint"`
+	SmallS      []byte `cbor:"2,keyasint"`
+	SmallN      []byte `cbor:"3,keyasint"`
+	K           []byte `cbor:"4,keyasint,omitempty"`
+	A           []byte `cbor:"5,keyasint"`
+	B           []byte `cbor:"6,keyasint"`
+	C           []byte `cbor:"7,keyasint"`
+	D           []byte `cbor:"8,keyasint"`
+	Epoch       uint32 `cbor:"9,keyasint,omitempty"`
+	Fingerprint []byte `cbor:"10,keyasint,omitempty"`
+}
+
+func (signature *Signature) EncodeCBOR() ([]byte, error) {
+	err := checkSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	mid := MiddleCBORSignature{
+		SmallC:      amcl_utils.BigToBytes(signature.Proof.SmallC),
+		SmallS:      amcl_utils.BigToBytes(signature.Proof.SmallS),
+		SmallN:      amcl_utils.BigToBytes(signature.Proof.SmallN),
+		A:           amcl_utils.EcpToBytes(signature.RandomizedCred.A),
+		B:           amcl_utils.EcpToBytes(signature.RandomizedCred.B),
+		C:           amcl_utils.EcpToBytes(signature.RandomizedCred.C),
+		D:           amcl_utils.EcpToBytes(signature.RandomizedCred.D),
+		Epoch:       signature.Epoch,
+		Fingerprint: signature.Fingerprint,
+	}
+
+	// only the signatures on a basename have the pseudonym K
+	if signature.Proof.K != nil {
+		mid.K = amcl_utils.EcpToBytes(signature.Proof.K)
+	}
+
+	return encodeCBOR(mid)
+}
+
+func (decoded *Signature) DecodeCBOR(encoded []byte) error {
+	var mid MiddleCBORSignature
+
+	err := decodeCBOR(encoded, &mid)
+	if err != nil {
+		return err
+	}
+
+	// the sizes and the points are checked as of the JSON encoding
+	scalars := []*FP256BN.BIG{}
+
+	for _, field := range []struct {
+		name string
+		buf  []byte
+	}{{"c", mid.SmallC}, {"s", mid.SmallS}, {"n", mid.SmallN}} {
+		x, err := jsonToBIG(field.name, field.buf)
+		if err != nil {
+			return err
+		}
+
+		scalars = append(scalars, x)
+	}
+
+	var K *FP256BN.ECP
+
+	if mid.K != nil {
+		K, err = jsonToECP("k", mid.K)
+		if err != nil {
+			return err
+		}
+	}
+
+	points := []*FP256BN.ECP{}
+
+	for _, field := range []struct {
+		name string
+		buf  []byte
+	}{{"a", mid.A}, {"b", mid.B}, {"c", mid.C}, {"d", mid.D}} {
+		P, err := jsonToECP(field.name, field.buf)
+		if err != nil {
+			return err
+		}
+
+		points = append(points, P)
+	}
+
+	decoded.Proof = &SchnorrProof{
+		SmallC: scalars[0],
+		SmallS: scalars[1],
+		SmallN: scalars[2],
+		K:      K,
+	}
+
+	decoded.RandomizedCred = &Credential{
+		A: points[0],
+		B: points[1],
+		C: points[2],
+		D: points[3],
+	}
+
+	decoded.Epoch = mid.Epoch
+	decoded.Fingerprint = mid.Fingerprint
+
+	return nil
+}
+
+/**
+ * Attestation statement of "packed" format of ECDAA type.
+ */
+type PackedAttestation struct {
+	Alg        int    `cbor:"alg"`
+	Sig        []byte `cbor:"sig"`
+	ECDAAKeyID []byte `cbor:"ecdaaKeyId"`
+}
+
+type AttestationObject struct {
+	Fmt      string            `cbor:"fmt"`
+	AuthData []byte            `cbor:"authData"`
+	AttStmt  PackedAttestation `cbor:"attStmt"`
+}
+
+func attestationMessage(authData, clientDataHash []byte) []byte {
+	message := append([]byte{}, authData...)
+
+	return append(message, clientDataHash...)
+}
+
+/**
+ * Build the attestation object of the authenticator data, attested by
+ * the signer with the credential under the IPK.
+ */
+func AttestPacked(signer Signer, ipk *IPK, authData, clientDataHash []byte, rng *core.RAND) ([]byte, error) {
+	signature, err := signer.Sign(attestationMessage(authData, clientDataHash), nil, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := signature.EncodeCBOR()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeCBOR(AttestationObject{
+		Fmt:      AttestationFormatPacked,
+		AuthData: authData,
+		AttStmt: PackedAttestation{
+			Alg:        COSEAlgECDAA,
+			Sig:        sig,
+			ECDAAKeyID: ECDAAKeyID(ipk),
+		},
+	})
+}
+
+/**
+ * Decode the attestation object and check it is "packed" of ECDAA type.
+ * The relying party picks the IPK by its ECDAAKeyID.
+ */
+func ParsePackedAttestation(encoded []byte) (*AttestationObject, error) {
+	var object AttestationObject
+
+	err := decodeCBOR(encoded, &object)
+	if err != nil {
+		return nil, err
+	}
+
+	if object.Fmt != AttestationFormatPacked {
+		return nil, verifyError(ErrMalformed, "format %v is not packed", object.Fmt)
+	}
+
+	if object.AttStmt.Alg != COSEAlgECDAA || object.AttStmt.ECDAAKeyID == nil {
+		return nil, verifyError(ErrMalformed, "attestation is not of ECDAA: alg %v", object.AttStmt.Alg)
+	}
+
+	return &object, nil
+}
+
+/**
+ * Verify the attestation object with the IPK, and return the authenticator data.
+ */
+func VerifyPackedAttestation(encoded, clientDataHash []byte, ipk *IPK, rl RevocationList) ([]byte, error) {
+	object, err := ParsePackedAttestation(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(object.AttStmt.ECDAAKeyID, ECDAAKeyID(ipk)) {
+		return nil, verifyError(ErrIPKUntrusted, "ecdaaKeyId is not of the IPK")
+	}
+
+	var signature Signature
+
+	err = signature.DecodeCBOR(object.AttStmt.Sig)
+	if err != nil {
+		return nil, err
+	}
+
+	if signature.Proof.K != nil {
+		return nil, verifyError(ErrMalformed, "attestation has the pseudonym K, which links it")
+	}
+
+	err = Verify(attestationMessage(object.AuthData, clientDataHash), nil, &signature, ipk, rl)
+	if err != nil {
+		return nil, err
+	}
+
+	return object.AuthData, nil
+}
+
+/**
+ * Find the trusted IPK by the ecdaaKeyId of the attestation.
+ */
+func (store *TrustStore) LookupECDAAKeyID(id []byte, now time.Time) (*TrustedIPK, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, entry := range store.entries {
+		if !bytes.Equal(ECDAAKeyID(entry.IPK), id) {
+			continue
+		}
+
+		if !entry.ValidAt(now) {
+			return nil, verifyError(ErrIPKUntrusted, "IPK of %v is not valid at %v", entry.Group, now)
+		}
+
+		return entry, nil
+	}
+
+	return nil, verifyError(ErrIPKUntrusted, "ecdaaKeyId is not found")
+}
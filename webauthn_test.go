@@ -0,0 +1,298 @@
+package ecdaa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	amcl_utils "github.com/akakou/fp256bn-amcl-utils"
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/akakou/ecdaa/tpm_utils"
+)
+
+func TestWebAuthnAttestation(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	now := time.Now()
+
+	issuer, signer, err := ExampleInitialize(rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	other := RandomIssuer(rng)
+
+	// rpIdHash || flags (UP, AT) || signCount
+	rpIDHash := sha256.Sum256([]byte("example.com"))
+	authData := append(rpIDHash[:], 0x41, 0, 0, 0, 1)
+	clientDataHash := sha256.Sum256([]byte(`{"type":"webauthn.create"}`))
+
+	t.Run("cose_ipk", func(t *testing.T) {
+		encoded, err := issuer.Ipk.EncodeCOSE()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var decoded IPK
+
+		err = decoded.DecodeCOSE(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if !bytes.Equal(IPKFingerprint(&decoded), IPKFingerprint(&issuer.Ipk)) {
+			t.Fatalf("IPK is not equal")
+		}
+	})
+
+	t.Run("cbor_signature", func(t *testing.T) {
+		signature, err := NewFingerprintSigner(signer, &issuer.Ipk).Sign([]byte("hoge"), []byte("fuga"), rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		encoded, err := signature.EncodeCBOR()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var decoded Signature
+
+		err = decoded.DecodeCBOR(encoded)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if !bytes.Equal(decoded.Fingerprint, signature.Fingerprint) {
+			t.Fatalf("fingerprint is not equal")
+		}
+
+		err = Verify([]byte("hoge"), []byte("fuga"), &decoded, &issuer.Ipk, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	})
+
+	t.Run("cbor_malformed", func(t *testing.T) {
+		encoded, err := encodeCBOR(MiddleCBORSignature{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var signature Signature
+		err = signature.DecodeCBOR(encoded)
+		expectReason(t, err, ErrMalformed, -1)
+
+		encoded, err = encodeCBOR(MiddleCOSEIPK{Kty: COSEKtyECDAA, Alg: COSEAlgECDAA})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var ipk IPK
+		err = ipk.DecodeCOSE(encoded)
+		expectReason(t, err, ErrMalformed, -1)
+
+		valid, err := signer.Sign([]byte("hoge"), nil, rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		mid := MiddleCBORSignature{
+			SmallC: amcl_utils.BigToBytes(valid.Proof.SmallC),
+			SmallS: amcl_utils.BigToBytes(valid.Proof.SmallS),
+			SmallN: amcl_utils.BigToBytes(valid.Proof.SmallN),
+			A:      amcl_utils.EcpToBytes(valid.RandomizedCred.A),
+			B:      amcl_utils.EcpToBytes(valid.RandomizedCred.B),
+			C:      amcl_utils.EcpToBytes(valid.RandomizedCred.C),
+			D:      amcl_utils.EcpToBytes(valid.RandomizedCred.D)[1:],
+		}
+
+		encoded, err = encodeCBOR(mid)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		err = signature.DecodeCBOR(encoded)
+		expectReason(t, err, ErrMalformed, -1)
+	})
+
+	attestation, err := AttestPacked(signer, &issuer.Ipk, authData, clientDataHash[:], rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	t.Run("verify", func(t *testing.T) {
+		object, err := ParsePackedAttestation(attestation)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		store := NewTrustStore()
+
+		for _, ipk := range []*IPK{&other.Ipk, &issuer.Ipk} {
+			_, err = store.Add(&TrustedIPK{IPK: ipk})
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+
+		entry, err := store.LookupECDAAKeyID(object.AttStmt.ECDAAKeyID, now)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		verified, err := VerifyPackedAttestation(attestation, clientDataHash[:], entry.IPK, RevocationList{})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if !bytes.Equal(verified, authData) {
+			t.Fatalf("authenticator data is not equal")
+		}
+	})
+
+	t.Run("client_data", func(t *testing.T) {
+		_, err := VerifyPackedAttestation(attestation, make([]byte, sha256.Size), &issuer.Ipk, RevocationList{})
+		if !errors.Is(err, ErrProofInvalid) {
+			t.Fatalf("attestation of other client data is not refused: %v", err)
+		}
+	})
+
+	t.Run("other_ipk", func(t *testing.T) {
+		_, err := VerifyPackedAttestation(attestation, clientDataHash[:], &other.Ipk, RevocationList{})
+		if !errors.Is(err, ErrIPKUntrusted) {
+			t.Fatalf("attestation is not refused with other IPK: %v", err)
+		}
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		_, err := VerifyPackedAttestation(attestation, clientDataHash[:], &issuer.Ipk, RevocationList{signer.sk})
+		if !errors.Is(err, ErrKeyRevoked) {
+			t.Fatalf("attestation of revoked key is not refused: %v", err)
+		}
+	})
+
+	t.Run("format", func(t *testing.T) {
+		var object AttestationObject
+
+		err := cbor.Unmarshal(attestation, &object)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		object.Fmt = "none"
+
+		encoded, err := cbor.Marshal(object)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = ParsePackedAttestation(encoded)
+		if !errors.Is(err, ErrMalformed) {
+			t.Fatalf("attestation of other format is not refused: %v", err)
+		}
+	})
+	t.Run("unlinkable", func(t *testing.T) {
+		other, err := AttestPacked(signer, &issuer.Ipk, authData, clientDataHash[:], rng)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		seen := map[string]bool{}
+
+		for i, encoded := range [][]byte{attestation, other} {
+			object, err := ParsePackedAttestation(encoded)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			var sig MiddleCBORSignature
+
+			err = decodeCBOR(object.AttStmt.Sig, &sig)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			if sig.K != nil {
+				t.Fatalf("attestation has the pseudonym K")
+			}
+
+			for _, value := range [][]byte{sig.SmallC, sig.SmallS, sig.SmallN, sig.A, sig.B, sig.C, sig.D} {
+				if i == 0 {
+					seen[string(value)] = true
+				} else if seen[string(value)] {
+					t.Fatalf("attestations have a common value %x", value)
+				}
+			}
+		}
+	})
+
+	t.Run("pseudonym", func(t *testing.T) {
+		var object AttestationObject
+
+		err := cbor.Unmarshal(attestation, &object)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var signature Signature
+
+		err = signature.DecodeCBOR(object.AttStmt.Sig)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		signature.Proof.K = amcl_utils.G1()
+
+		object.AttStmt.Sig, err = signature.EncodeCBOR()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		encoded, err := cbor.Marshal(object)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		_, err = VerifyPackedAttestation(encoded, clientDataHash[:], &issuer.Ipk, RevocationList{})
+		if !errors.Is(err, ErrMalformed) {
+			t.Fatalf("attestation with K is not refused: %v", err)
+		}
+	})
+}
+
+func TestWebAuthnAttestationTPM(t *testing.T) {
+	rng := amcl_utils.InitRandom()
+	password := []byte("piyo")
+
+	tpm, err := tpm_utils.OpenTPM(password, tpm_utils.TPM_PATH)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer tpm.Close()
+
+	issuer, signer, err := ExampleTPMInitialize(tpm, rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	rpIDHash := sha256.Sum256([]byte("example.com"))
+	authData := append(rpIDHash[:], 0x41, 0, 0, 0, 1)
+	clientDataHash := sha256.Sum256([]byte(`{"type":"webauthn.create"}`))
+
+	attestation, err := AttestPacked(signer, &issuer.Ipk, authData, clientDataHash[:], rng)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	verified, err := VerifyPackedAttestation(attestation, clientDataHash[:], &issuer.Ipk, RevocationList{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if !bytes.Equal(verified, authData) {
+		t.Fatalf("authenticator data is not equal")
+	}
+}